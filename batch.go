@@ -0,0 +1,219 @@
+package sql_wrapper
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	// DefaultBatchSize is the number of rows in one statement of a batch when no size is set
+	DefaultBatchSize = 1000
+
+	// DefaultValueLimit is the number of values bound in one statement of a batch when no limit is set, which is the
+	// most placeholders MySQL allows in a prepared statement
+	DefaultValueLimit = 65535
+)
+
+// BatchConfig configures how batch operations group rows into statements. Zero values use the defaults
+type BatchConfig struct {
+	Size       int // The maximum number of rows in one statement
+	ValueLimit int // The maximum number of values bound in one statement
+}
+
+// validate checks that the sizes of the config are not negative
+func (c BatchConfig) validate() error {
+	if c.Size < 0 {
+		return fmt.Errorf("batch size cannot be negative")
+	} else if c.ValueLimit < 0 {
+		return fmt.Errorf("batch value limit cannot be negative")
+	}
+	return nil
+}
+
+// rows gets the number of rows with the given number of columns that fit in one statement
+func (c BatchConfig) rows(columns int) int {
+	size := c.Size
+	if size == 0 {
+		size = DefaultBatchSize
+	}
+
+	limit := c.ValueLimit
+	if limit == 0 {
+		limit = DefaultValueLimit
+	}
+
+	// Every statement holds at least one row
+	if columns > 0 && limit/columns < size {
+		size = limit / columns
+	}
+	if size < 1 {
+		size = 1
+	}
+
+	return size
+}
+
+// chunkSQL creates insert statements for rows of values, grouping as many rows as the config allows into each statement.
+// The arguments of the rows are bound in the order of the rows
+func (c BatchConfig) chunkSQL(table string, columns []string, rows []statement) []statement {
+	statements := []statement{}
+	size := c.rows(len(columns))
+
+	for start := 0; start < len(rows); start += size {
+		end := start + size
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		values := []string{}
+		args := []interface{}{}
+		for _, row := range rows[start:end] {
+			values = append(values, row.query)
+			args = append(args, row.args...)
+		}
+
+		statements = append(statements, statement{query: fmt.Sprintf("INSERT INTO %v (%v) VALUES %v;", table, strings.Join(columns, ", "), strings.Join(values, ", ")), args: args})
+	}
+
+	return statements
+}
+
+// insertManySQL creates strings that will insert objects with the given IDs, writing the rows of the table and of
+// every slice relation field with multi-row statements
+func (s *schema) insertManySQL(ids []int, vals []Readable) ([]statement, error) {
+	statements := []statement{}
+
+	// Make sure the table name is set
+	if s.table == "" {
+		return statements, fmt.Errorf("cannot insert record with no table name")
+	}
+
+	var columns []string
+	rows := []statement{}
+	links := make([][]statement, len(s.plan))
+
+	for i, val := range vals {
+		v := reflect.ValueOf(val).Elem()
+
+		cols, values, err := s.rowSQL(ids[i], v)
+		if err != nil {
+			return statements, err
+		}
+		columns = cols
+		rows = append(rows, statement{query: placeholders(len(values)), args: values})
+
+		// Collect the links of the slice relation fields of every object
+		for _, p := range s.plan {
+			if p.skip || !p.list() {
+				continue
+			}
+
+			fieldLinks, err := s.links(p.field, v.Field(p.index), 0)
+			if err != nil {
+				return statements, err
+			}
+
+			for _, row := range fieldLinks {
+				links[p.index] = append(links[p.index], row.placeholders(ids[i]))
+			}
+		}
+	}
+
+	statements = append(statements, s.batch.chunkSQL(s.table, columns, rows)...)
+
+	for _, p := range s.plan {
+		if len(links[p.index]) == 0 {
+			continue
+		}
+
+		linkColumns := append([]string{p.name, s.table + "ID"}, p.extras...)
+		statements = append(statements, s.batch.chunkSQL(p.junction, linkColumns, links[p.index])...)
+	}
+
+	return statements, nil
+}
+
+// insertMany inserts new entries in one transaction and returns their IDs in the order of the objects
+func (s *schema) insertMany(tx *Tx, vals []Readable) ([]int, error) {
+	ids := []int{}
+	if len(vals) == 0 {
+		return ids, nil
+	}
+
+	// Make sure every object is new and only given once
+	seen := map[interface{}]bool{}
+	for _, val := range vals {
+		if _, err := s.validate(val); err == nil {
+			return ids, fmt.Errorf("object is already in schema")
+		}
+
+		key, err := identityKey(val)
+		if err != nil {
+			return ids, err
+		} else if seen[key] {
+			return ids, fmt.Errorf("object is given more than once")
+		}
+		seen[key] = true
+	}
+
+	// Start a transaction in the database
+	t, err := s.begin(tx)
+	if err != nil {
+		return ids, err
+	}
+
+	// Add the objects to the internal map so references between them resolve
+	for _, val := range vals {
+		id := s.nextID
+		tx.touch(s, id)
+		s.nextID++
+
+		s.add(id, val)
+		ids = append(ids, id)
+	}
+
+	// Rollback the transaction, remove the objects and restore the owners if there is an error
+	owners := []saveStep{}
+	defer func() {
+		if err != nil {
+			t.Rollback()
+			for _, id := range ids {
+				s.remove(id)
+			}
+			restoreSteps(owners)
+		}
+	}()
+
+	// Add the objects to SQL
+	strs, err := s.insertManySQL(ids, vals)
+	if err != nil {
+		return ids, err
+	}
+
+	// Save owners that reference the objects through inverse fields, updating each owner once
+	var inverseStrs []statement
+	inverseStrs, owners, err = s.pushInversesSQL(tx, t, vals...)
+	if err != nil {
+		return ids, err
+	}
+	strs = append(strs, inverseStrs...)
+
+	for _, str := range strs {
+		_, err = t.Exec(str.query, str.args...)
+		if err != nil {
+			return ids, err
+		}
+	}
+
+	if err = t.Commit(); err != nil {
+		return ids, err
+	}
+	s.persist(ids...)
+	persistSteps(owners)
+
+	// Update inverse fields that mirror the schema
+	manager.syncInverses(s, vals...)
+	manager.syncSteps(owners)
+	return ids, nil
+}
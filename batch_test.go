@@ -0,0 +1,117 @@
+package sql_wrapper_test
+
+import (
+	"testing"
+
+	sql_wrapper "github.com/ethanbaker/sql-wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Tests ----------
+
+func TestInsertMany(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	// Use small statements so the objects are split across several of them
+	assert.Nil(wrapper.SetBatch(sql_wrapper.BatchConfig{Size: 2}))
+
+	objs := []*TestObject{
+		{Name: "Jack", Age: 20, Weather: Summer},
+		{Name: "Jill", Age: 21, Weather: Autumn},
+		{Name: "John", Age: 22, Weather: Winter},
+	}
+
+	ids, err := wrapper.InsertMany(objs)
+	assert.Nil(err)
+	assert.Equal(3, len(ids))
+
+	// The IDs are returned in the order of the objects
+	for i, obj := range objs {
+		id, err := wrapper.GetID(obj)
+		assert.Nil(err)
+		assert.Equal(ids[i], id)
+
+		var name string
+		assert.Nil(database.QueryRow("SELECT Name FROM TestObject WHERE id = ?", id).Scan(&name))
+		assert.Equal(obj.Name, name)
+	}
+
+	// Objects that are already inserted cannot be inserted again
+	_, err = wrapper.InsertMany([]*TestObject{objs[0]})
+	assert.NotNil(err)
+
+	// Objects cannot be given more than once
+	obj := TestObject{Name: "Jane", Weather: Spring}
+	_, err = wrapper.InsertMany([]*TestObject{&obj, &obj})
+	assert.NotNil(err)
+
+	// Negative sizes are rejected
+	assert.NotNil(wrapper.SetBatch(sql_wrapper.BatchConfig{Size: -1}))
+	assert.NotNil(wrapper.SetBatch(sql_wrapper.BatchConfig{ValueLimit: -1}))
+}
+
+func TestInsertManyInverse(t *testing.T) {
+	inverseSetup()
+	assert := assert.New(t)
+
+	author := Author{Name: "Jack"}
+	_, err := authorWrapper.Insert(&author)
+	assert.Nil(err)
+
+	// Books that set the same author should add every book to the author
+	books := []*Book{{Title: "First", Author: &author}, {Title: "Second", Author: &author}}
+	_, err = bookWrapper.InsertMany(books)
+	assert.Nil(err)
+
+	assert.Equal(books, author.Books)
+
+	var count int
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM AuthorBooks").Scan(&count))
+	assert.Equal(2, count)
+}
+
+func TestInsertManyLinks(t *testing.T) {
+	cascadeSetup()
+	assert := assert.New(t)
+
+	cars := []*Car{{Model: "Coupe"}, {Model: "Sedan"}, {Model: "Van"}}
+	_, err := carWrapper.InsertMany(cars)
+	assert.Nil(err)
+
+	garages := []*Garage{
+		{Name: "Main", Cars: cars[:2]},
+		{Name: "Side", Cars: cars[2:]},
+	}
+
+	// Links are written in bulk with the garages
+	_, err = garageWrapper.InsertMany(garages)
+	assert.Nil(err)
+
+	var count int
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM GarageCars").Scan(&count))
+	assert.Equal(3, count)
+}
+
+func TestInsertManyRollback(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	objs := []*TestObject{
+		{Name: "Jack", Weather: Summer},
+		{Name: "Jack", Weather: "Not a season"},
+	}
+
+	// A failed insert should not insert any object
+	_, err := wrapper.InsertMany(objs)
+	assert.NotNil(err)
+
+	for _, obj := range objs {
+		_, err := wrapper.GetID(obj)
+		assert.NotNil(err)
+	}
+
+	var count int
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM TestObject").Scan(&count))
+	assert.Equal(0, count)
+}
@@ -0,0 +1,219 @@
+package sql_wrapper
+
+import (
+	"reflect"
+)
+
+// saveStep is an object saved while cascading a save through relation fields
+type saveStep struct {
+	schema *schema  // The schema the object belongs to
+	object Readable // The object to save
+	insert bool     // Whether the object is inserted instead of updated
+
+	index int           // The index of the relation field an inverse field changed
+	old   reflect.Value // The value the relation field had before an inverse field changed it
+}
+
+// cascades is a helper method that checks if the schema has a relation field that cascades saves
+func (s *schema) cascades() bool {
+	for _, p := range s.plan {
+		if p.cascade {
+			return true
+		}
+	}
+	return false
+}
+
+// cascadeTargets is a helper method that gets the objects an object references through relation fields that cascade saves
+func (s *schema) cascadeTargets(val Readable) ([]Readable, error) {
+	targets := []Readable{}
+
+	v := reflect.ValueOf(val).Elem()
+	for _, p := range s.plan {
+		if !p.cascade {
+			continue
+		}
+		field := v.Field(p.index)
+
+		// Only the loaded targets of lazy relations can be saved
+		if p.lazy {
+			for _, target := range getLazy(field).targets() {
+				if target.object != nil {
+					targets = append(targets, target.object)
+				}
+			}
+			continue
+		}
+
+		if p.pointer() {
+			if field.IsNil() {
+				continue
+			}
+
+			target, ok := field.Interface().(Readable)
+			if ok {
+				targets = append(targets, target)
+			}
+			continue
+		}
+
+		for k := 0; k < field.Len(); k++ {
+			target, err := getLinkTarget(p.field, field.Index(k))
+			if err != nil {
+				return targets, err
+			}
+			targets = append(targets, target)
+		}
+	}
+
+	return targets, nil
+}
+
+// savePlan walks the relation fields that cascade saves and orders the objects so every object is saved after the
+// objects it references
+func (s *schema) savePlan(val Readable) ([]saveStep, error) {
+	steps := []saveStep{}
+	visited := map[Readable]bool{}
+
+	var visit func(s *schema, val Readable) error
+	visit = func(s *schema, val Readable) error {
+		if visited[val] {
+			return nil
+		}
+		visited[val] = true
+
+		// Save the referenced objects first
+		targets, err := s.cascadeTargets(val)
+		if err != nil {
+			return err
+		}
+
+		for _, target := range targets {
+			targetSchema, err := manager.getSchema(reflect.TypeOf(target).Elem().Name())
+			if err != nil {
+				return err
+			}
+
+			if err := visit(targetSchema, target); err != nil {
+				return err
+			}
+		}
+
+		_, err = s.validate(val)
+		steps = append(steps, saveStep{schema: s, object: val, insert: err != nil})
+		return nil
+	}
+
+	return steps, visit(s, val)
+}
+
+// saveCascade saves an object together with the objects it references through relation fields that cascade saves.
+// Unsaved objects are inserted and saved objects are updated in one transaction
+func (s *schema) saveCascade(tx *Tx, val Readable) error {
+	steps, err := s.savePlan(val)
+	if err != nil {
+		return err
+	}
+
+	// Register the unsaved objects so every object has an ID before the statements are created
+	for _, step := range steps {
+		if step.insert {
+			id := step.schema.nextID
+			tx.touch(step.schema, id)
+			step.schema.nextID++
+
+			step.schema.add(id, step.object)
+		}
+	}
+
+	// Start a transaction in the database
+	t, err := s.begin(tx)
+	if err != nil {
+		s.unregister(steps)
+		return err
+	}
+
+	// Rollback the transaction, remove the inserted objects and restore the owners if there is an error
+	owners := []saveStep{}
+	defer func() {
+		if err != nil {
+			t.Rollback()
+			s.unregister(steps)
+			restoreSteps(owners)
+		}
+	}()
+
+	strs := []statement{}
+	for _, step := range steps {
+		var (
+			id          int
+			objStrs     []statement
+			inverseStrs []statement
+			pushed      []saveStep
+		)
+
+		id, err = step.schema.getID(step.object)
+		if err != nil {
+			return err
+		}
+
+		// Insert or update the object
+		tx.touch(step.schema, id)
+		if step.insert {
+			objStrs, err = step.schema.insertSQL(id, step.object)
+		} else {
+			objStrs, err = step.schema.updateSQL(t, id, step.object)
+		}
+		if err != nil {
+			return err
+		}
+		strs = append(strs, objStrs...)
+
+		// Save owners that reference the object through inverse fields
+		inverseStrs, pushed, err = step.schema.pushInversesSQL(tx, t, step.object)
+		if err != nil {
+			return err
+		}
+		strs = append(strs, inverseStrs...)
+		owners = append(owners, pushed...)
+	}
+
+	for _, str := range strs {
+		_, err = t.Exec(str.query, str.args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = t.Commit(); err != nil {
+		return err
+	}
+
+	persistSteps(append(steps, owners...))
+
+	// Update inverse fields that mirror the schemas
+	manager.syncSteps(append(steps, owners...))
+	return nil
+}
+
+// persistSteps is a helper method that records the state of saved objects once their statements are committed
+func persistSteps(steps []saveStep) {
+	for _, step := range steps {
+		if id, err := step.schema.getID(step.object); err == nil {
+			step.schema.persist(id)
+		}
+	}
+}
+
+// unregister is a helper method that removes the objects inserted by a failed cascading save from their schemas
+func (s *schema) unregister(steps []saveStep) {
+	for _, step := range steps {
+		if !step.insert {
+			continue
+		}
+
+		if obj, err := step.schema.validate(step.object); err == nil {
+			step.schema.remove(obj.GetID())
+		}
+	}
+}
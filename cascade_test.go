@@ -0,0 +1,188 @@
+package sql_wrapper_test
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"testing"
+
+	sql_wrapper "github.com/ethanbaker/sql-wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Types ----------
+
+// Car is used as the target of a relation that cascades saves
+type Car struct {
+	Model string `sql:"Model" def:"VARCHAR(16)"`
+}
+
+// Read reads in Cars from an SQL query
+func (c Car) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM Car")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id    int
+		model string
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &model); err != nil {
+			return items, err
+		}
+
+		obj := Car{Model: model}
+		items[id] = &obj
+	}
+
+	return items, nil
+}
+
+// Garage is used to test relations that cascade saves
+type Garage struct {
+	Name string `sql:"Name" def:"VARCHAR(128)"`
+	Cars []*Car `sql:"CarID" rel:"one-to-many" cascade:"save"`
+}
+
+// Read reads in Garages from an SQL query
+func (g Garage) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM Garage")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id   int
+		name string
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &name); err != nil {
+			return items, err
+		}
+
+		obj := Garage{Name: name}
+		items[id] = &obj
+	}
+
+	// Query the related elements
+	rows, err = db.Query("SELECT GarageID, CarID FROM GarageCars")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		garageID int
+		carID    int
+	)
+	for rows.Next() {
+		if err := rows.Scan(&garageID, &carID); err != nil {
+			return items, err
+		}
+
+		// Get the referenced object from another schema
+		readable, err := sql_wrapper.GetObjectBySchema("Car", carID)
+		if err != nil {
+			return items, err
+		}
+		car, ok := readable.(*Car)
+		if !ok {
+			return items, fmt.Errorf("cannot cast object to *Car")
+		}
+
+		garage := items[garageID].(*Garage)
+		garage.Cars = append(garage.Cars, car)
+	}
+
+	return items, nil
+}
+
+// ---------- Globals ----------
+
+var carWrapper *sql_wrapper.Wrapper[*Car]
+var garageWrapper *sql_wrapper.Wrapper[*Garage]
+
+// ---------- Tests ----------
+
+func TestCascadeSave(t *testing.T) {
+	cascadeSetup()
+	assert := assert.New(t)
+
+	first := Car{Model: "Coupe"}
+	second := Car{Model: "Sedan"}
+	garage := Garage{Name: "Main", Cars: []*Car{&first, &second}}
+
+	// Saving the garage should insert the unsaved cars
+	assert.Nil(garageWrapper.Save(&garage))
+
+	firstID, err := carWrapper.GetID(&first)
+	assert.Nil(err)
+
+	_, err = carWrapper.GetID(&second)
+	assert.Nil(err)
+
+	var count int
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM GarageCars").Scan(&count))
+	assert.Equal(2, count)
+
+	// Saving the garage again should update the saved cars
+	first.Model = "Roadster"
+	assert.Nil(garageWrapper.Save(&garage))
+
+	var model string
+	assert.Nil(database.QueryRow("SELECT Model FROM Car WHERE id = ?", firstID).Scan(&model))
+	assert.Equal("Roadster", model)
+}
+
+func TestCascadeSaveRollback(t *testing.T) {
+	cascadeSetup()
+	assert := assert.New(t)
+
+	car := Car{Model: "A model name that is too long"}
+	garage := Garage{Name: "Main", Cars: []*Car{&car}}
+
+	// A failed save should not insert any object
+	assert.NotNil(garageWrapper.Save(&garage))
+
+	_, err := carWrapper.GetID(&car)
+	assert.NotNil(err)
+
+	_, err = garageWrapper.GetID(&garage)
+	assert.NotNil(err)
+
+	var count int
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM Garage").Scan(&count))
+	assert.Equal(0, count)
+}
+
+// ---------- Test Setup ----------
+
+func cascadeSetup() {
+	// Drop the current wrapper
+	dropTables()
+
+	// Create the new wrappers
+	var err error
+	carWrapper, err = sql_wrapper.NewWrapper[*Car](database, Car{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	garageWrapper, err = sql_wrapper.NewWrapper[*Garage](database, Garage{})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
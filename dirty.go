@@ -0,0 +1,270 @@
+package sql_wrapper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FieldChange is a field of an object that differs from the state last written to or read from the database
+type FieldChange struct {
+	Field  string // The name of the struct field
+	Column string // The column, or the target column of a slice relation, that stores the field
+	Old    string // The stored value as it is compared, or empty if the stored state is unknown
+	New    string // The current value as it is compared
+}
+
+// fieldState is a helper method that gets the value of a field as it is compared with its recorded state. Pointer
+// relations are compared by the ID they store, and slice relations by their links, which are sorted unless the
+// relation keeps its order. The value is only used for comparing, since statements bind the values of fields
+func (s *schema) fieldState(p fieldPlan, v reflect.Value) (string, error) {
+	if p.rel == UndefinedRelationType {
+		return fmt.Sprintf("%#v", v.Interface()), nil
+	} else if p.pointer() {
+		return p.referenceSQL(v)
+	}
+
+	rows, err := s.links(p.field, v, 0)
+	if err != nil {
+		return "", err
+	}
+
+	links := []string{}
+	for _, row := range rows {
+		str := fmt.Sprint(row.target)
+		if len(row.extras) > 0 {
+			values := []string{}
+			for _, extra := range row.extras {
+				values = append(values, fmt.Sprintf("%#v", extra))
+			}
+			str += fmt.Sprintf(" (%v)", strings.Join(values, ", "))
+		}
+		links = append(links, str)
+	}
+	if !p.ordered {
+		sort.Strings(links)
+	}
+
+	return "[" + strings.Join(links, ", ") + "]", nil
+}
+
+// state is a helper method that gets the values of every stored field of an object as they are compared
+func (s *schema) state(v reflect.Value) ([]string, error) {
+	state := make([]string, len(s.plan))
+	for _, p := range s.plan {
+		if p.skip {
+			continue
+		}
+
+		str, err := s.fieldState(p, v.Field(p.index))
+		if err != nil {
+			return nil, err
+		}
+		state[p.index] = str
+	}
+
+	return state, nil
+}
+
+// persisted is the state of an object as last written to or read from the database
+type persisted struct {
+	fields []string // The stored fields as they are compared
+	object Readable // A copy of the object
+}
+
+// persist is a helper method that records the state of the objects with the given IDs once they match the database.
+// Objects whose state cannot be made are written in full on their next update
+func (s *schema) persist(ids ...int) {
+	if s.states == nil {
+		s.states = make(map[int]persisted)
+	}
+
+	for _, id := range ids {
+		obj, ok := s.objects[id]
+		if !ok {
+			continue
+		}
+
+		state, err := s.state(reflect.ValueOf(obj.Object()).Elem())
+		if err != nil {
+			delete(s.states, id)
+			continue
+		}
+		s.states[id] = persisted{fields: state, object: s.snapshot(obj.Object())}
+	}
+}
+
+// persistAll is a helper method that records the state of every object in the schema
+func (s *schema) persistAll() {
+	for id := range s.objects {
+		s.persist(id)
+	}
+}
+
+// persistField is a helper method that records the state of one field of an object once it matches the database,
+// keeping the other fields pending
+func (s *schema) persistField(id int, index int) {
+	state, ok := s.states[id]
+	obj, found := s.objects[id]
+	if !ok || !found {
+		return
+	}
+
+	field := reflect.ValueOf(obj.Object()).Elem().Field(index)
+	str, err := s.fieldState(s.plan[index], field)
+	if err != nil {
+		delete(s.states, id)
+		return
+	}
+
+	// Replace the state instead of changing it, since transactions keep the states they restore on rollback
+	state = persisted{fields: append([]string{}, state.fields...), object: s.snapshot(state.object)}
+	state.fields[index] = str
+	s.copyField(s.plan[index], reflect.ValueOf(state.object).Elem().Field(index), field)
+	s.states[id] = state
+}
+
+// persistDrop is a helper method that removes a deleted object from a relation field of the recorded state of an
+// object, matching the change the database made to its row when the object was deleted
+func (s *schema) persistDrop(id int, ref reference, val Readable, valID int) {
+	state, ok := s.states[id]
+	if !ok || !ref.references(reflect.ValueOf(state.object).Elem().Field(ref.index), val, valID) {
+		return
+	}
+
+	// Replace the state instead of changing it, since transactions keep the states they restore on rollback
+	state = persisted{fields: append([]string{}, state.fields...), object: s.snapshot(state.object)}
+
+	field := reflect.ValueOf(state.object).Elem().Field(ref.index)
+	ref.drop(field, val, valID)
+
+	str, err := s.fieldState(s.plan[ref.index], field)
+	if err != nil {
+		delete(s.states, id)
+		return
+	}
+	state.fields[ref.index] = str
+	s.states[id] = state
+}
+
+// snapshot is a helper method that copies an object. Relation slices and junction structs are copied so changes to
+// the object do not change the copy, while the objects they reference are shared
+func (s *schema) snapshot(val Readable) Readable {
+	v := reflect.ValueOf(val).Elem()
+
+	c := reflect.New(v.Type())
+	c.Elem().Set(v)
+	for _, p := range s.plan {
+		s.copyField(p, c.Elem().Field(p.index), v.Field(p.index))
+	}
+
+	return c.Interface().(Readable)
+}
+
+// copyField is a helper method that sets a field of a copied object to a copy of the field of the original object
+func (s *schema) copyField(p fieldPlan, dst reflect.Value, src reflect.Value) {
+	if !dst.CanSet() {
+		return
+	}
+
+	if p.lazy {
+		getLazy(dst).setTargets(getLazy(src).targets())
+		return
+	} else if src.Kind() != reflect.Slice || src.IsNil() {
+		dst.Set(src)
+		return
+	}
+
+	_, junction := getJunctionStruct(p.field)
+
+	slice := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+	for i := 0; i < src.Len(); i++ {
+		elem := src.Index(i)
+		if junction && !elem.IsNil() {
+			// Junction structs belong to the link, so each copy has its own
+			copied := reflect.New(elem.Type().Elem())
+			copied.Elem().Set(elem.Elem())
+			elem = copied
+		}
+		slice.Index(i).Set(elem)
+	}
+	dst.Set(slice)
+}
+
+// cleanField is a helper method that checks if a value of a field of an object matches its recorded state
+func (s *schema) cleanField(id int, index int, value interface{}) bool {
+	state, ok := s.states[id]
+	if !ok {
+		return false
+	}
+
+	// Lazy relations need an addressable value
+	v := reflect.New(s.plan[index].field.Type).Elem()
+	v.Set(reflect.ValueOf(value))
+
+	str, err := s.fieldState(s.plan[index], v)
+	return err == nil && str == state.fields[index]
+}
+
+// dirty is a helper method that gets the plans of the stored fields of an object that differ from its recorded
+// state. Every stored field is dirty if the state is unknown
+func (s *schema) dirty(id int, state []string) []fieldPlan {
+	plans := []fieldPlan{}
+
+	old, ok := s.states[id]
+	for _, p := range s.plan {
+		if !p.skip && (!ok || old.fields[p.index] != state[p.index]) {
+			plans = append(plans, p)
+		}
+	}
+
+	return plans
+}
+
+// updateReturningOld updates an entry and returns a copy of it as it was last written to or read from the database
+func (s *schema) updateReturningOld(tx *Tx, val Readable) (Readable, error) {
+	obj, err := s.validate(val)
+	if err != nil {
+		return nil, err
+	}
+
+	state, ok := s.states[obj.GetID()]
+	if !ok {
+		return nil, fmt.Errorf("previous version of object is not known")
+	}
+
+	// Copy the stored copy so it does not change when the state is recorded again
+	old := s.snapshot(state.object)
+	if err := s.update(tx, val); err != nil {
+		return nil, err
+	}
+
+	return old, nil
+}
+
+// changes gets the stored fields of an object that differ from the state last written to or read from the database
+func (s *schema) changes(val Readable) ([]FieldChange, error) {
+	changes := []FieldChange{}
+
+	obj, err := s.validate(val)
+	if err != nil {
+		return changes, err
+	}
+
+	state, err := s.state(reflect.ValueOf(obj.Object()).Elem())
+	if err != nil {
+		return changes, err
+	}
+
+	old, ok := s.states[obj.GetID()]
+	for _, p := range s.dirty(obj.GetID(), state) {
+		change := FieldChange{Field: p.field.Name, Column: p.name, New: state[p.index]}
+		if ok {
+			change.Old = old.fields[p.index]
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,179 @@
+package sql_wrapper_test
+
+import (
+	"testing"
+
+	sql_wrapper "github.com/ethanbaker/sql-wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Tests ----------
+
+func TestChanges(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	obj := TestObject{Name: "Jack", Age: 20, Weather: Summer}
+	id, err := wrapper.Insert(&obj)
+	assert.Nil(err)
+
+	// Inserted objects have no pending changes
+	changes, err := wrapper.Changes(&obj)
+	assert.Nil(err)
+	assert.Empty(changes)
+
+	obj.Age = 21
+	changes, err = wrapper.Changes(&obj)
+	assert.Nil(err)
+	assert.Equal([]sql_wrapper.FieldChange{{Field: "Age", Column: "Age", Old: "20", New: "21"}}, changes)
+
+	// Updating writes the changes
+	assert.Nil(wrapper.Update(&obj))
+
+	changes, err = wrapper.Changes(&obj)
+	assert.Nil(err)
+	assert.Empty(changes)
+
+	// Updated values are bound to the statement, so they are written as they are
+	obj.Name = "O'Brien \"Jack\" \x01"
+	assert.Nil(wrapper.Update(&obj))
+
+	var name string
+	assert.Nil(database.QueryRow("SELECT Name FROM TestObject WHERE id = ?", id).Scan(&name))
+	assert.Equal(obj.Name, name)
+
+	// Objects that are not registered have no changes
+	_, err = wrapper.Changes(&TestObject{})
+	assert.NotNil(err)
+}
+
+func TestUpdateOnlyChanges(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	obj := TestObject{Name: "Jack", Age: 20, Weather: Summer}
+	id, err := wrapper.Insert(&obj)
+	assert.Nil(err)
+
+	// Change a column behind the wrapper's back
+	_, err = database.Exec("UPDATE TestObject SET Name = 'John' WHERE id = ?", id)
+	assert.Nil(err)
+
+	// Updating an object without changes skips the write
+	assert.Nil(wrapper.Update(&obj))
+
+	var (
+		name string
+		age  int
+	)
+	assert.Nil(database.QueryRow("SELECT Name FROM TestObject WHERE id = ?", id).Scan(&name))
+	assert.Equal("John", name)
+
+	// Updating only writes the changed columns
+	obj.Age = 21
+	assert.Nil(wrapper.Save(&obj))
+
+	assert.Nil(database.QueryRow("SELECT Name, Age FROM TestObject WHERE id = ?", id).Scan(&name, &age))
+	assert.Equal("John", name)
+	assert.Equal(21, age)
+}
+
+func TestChangesFailedUpdate(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	obj := TestObject{Name: "Jack", Age: 20, Weather: Summer}
+	_, err := wrapper.Insert(&obj)
+	assert.Nil(err)
+
+	// A failed update keeps the state, so only the changed fields are pending
+	obj.Weather = "Not a season"
+	assert.NotNil(wrapper.Update(&obj))
+
+	changes, err := wrapper.Changes(&obj)
+	assert.Nil(err)
+	assert.Equal([]sql_wrapper.FieldChange{{Field: "Weather", Column: "Weather", Old: "\"Summer\"", New: "\"Not a season\""}}, changes)
+}
+
+func TestChangesRelation(t *testing.T) {
+	cascadeSetup()
+	assert := assert.New(t)
+
+	cars := []*Car{{Model: "Coupe"}, {Model: "Sedan"}}
+	_, err := carWrapper.InsertMany(cars)
+	assert.Nil(err)
+
+	garage := Garage{Name: "Main", Cars: cars[:1]}
+	_, err = garageWrapper.Insert(&garage)
+	assert.Nil(err)
+
+	// Changing a relation field changes its links
+	garage.Cars = cars
+	changes, err := garageWrapper.Changes(&garage)
+	assert.Nil(err)
+	assert.Equal(1, len(changes))
+	assert.Equal("Cars", changes[0].Field)
+
+	// Relation mutations write the field they change
+	assert.Nil(garageWrapper.SetRelation(&garage, "Cars", cars[0], cars[1]))
+
+	changes, err = garageWrapper.Changes(&garage)
+	assert.Nil(err)
+	assert.Empty(changes)
+
+	// Changes to other fields stay pending and keep their old values
+	garage.Name = "Side"
+	assert.Nil(garageWrapper.RemoveRelation(&garage, "Cars", cars[1]))
+
+	changes, err = garageWrapper.Changes(&garage)
+	assert.Nil(err)
+	assert.Equal([]sql_wrapper.FieldChange{{Field: "Name", Column: "Name", Old: "\"Main\"", New: "\"Side\""}}, changes)
+
+	old, err := garageWrapper.UpdateReturningOld(&garage)
+	assert.Nil(err)
+	assert.Equal("Main", old.Name)
+	assert.Equal([]*Car{cars[0]}, old.Cars)
+}
+
+func TestUpdateReturningOld(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	obj := TestObject{Name: "Jack", Age: 20, Weather: Summer}
+	_, err := wrapper.Insert(&obj)
+	assert.Nil(err)
+
+	obj.Age = 21
+	old, err := wrapper.UpdateReturningOld(&obj)
+	assert.Nil(err)
+	assert.False(old == &obj)
+	assert.Equal(20, old.Age)
+	assert.Equal("Jack", old.Name)
+
+	// The returned copy does not change with later updates
+	obj.Age = 22
+	previous, err := wrapper.UpdateReturningOld(&obj)
+	assert.Nil(err)
+	assert.Equal(21, previous.Age)
+	assert.Equal(20, old.Age)
+}
+
+func TestUpdateReturningOldRelation(t *testing.T) {
+	cascadeSetup()
+	assert := assert.New(t)
+
+	cars := []*Car{{Model: "Coupe"}, {Model: "Sedan"}}
+	_, err := carWrapper.InsertMany(cars)
+	assert.Nil(err)
+
+	garage := Garage{Name: "Main", Cars: []*Car{cars[0]}}
+	_, err = garageWrapper.Insert(&garage)
+	assert.Nil(err)
+
+	// Changing the relation slice in place does not change the old version
+	garage.Cars[0] = cars[1]
+	old, err := garageWrapper.UpdateReturningOld(&garage)
+	assert.Nil(err)
+	assert.Equal([]*Car{cars[0]}, old.Cars)
+	assert.True(old.Cars[0] == cars[0])
+}
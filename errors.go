@@ -0,0 +1,22 @@
+package sql_wrapper
+
+import "fmt"
+
+// UnregisteredSchemaError is returned when a schema is looked up before a wrapper is created for it
+type UnregisteredSchemaError struct {
+	Name string // The name of the schema
+}
+
+func (e *UnregisteredSchemaError) Error() string {
+	return fmt.Sprintf("schema '%v' is not in schemaManager", e.Name)
+}
+
+// ObjectNotFoundError is returned when a schema does not have an object with an ID
+type ObjectNotFoundError struct {
+	Schema string // The name of the schema
+	ID     int    // The ID that was looked up
+}
+
+func (e *ObjectNotFoundError) Error() string {
+	return fmt.Sprintf("no object with id %v in schema '%v'", e.ID, e.Schema)
+}
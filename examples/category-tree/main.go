@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	sql_wrapper "github.com/ethanbaker/sql-wrapper"
+	"github.com/go-sql-driver/mysql"
+)
+
+// ---------- Consts ----------
+
+// SQL credentials
+var cfg = mysql.Config{
+	User:   "sql_wrapper_example",
+	Passwd: "abc123",
+	Net:    "tcp",
+	Addr:   "127.0.0.1:3306",
+	DBName: "sql_wrapper_example",
+}
+
+// User Prompts
+const AskPrompt = `Enter Choice:
+1 - View categories
+2 - Add category
+3 - Move category
+4 - Delete category
+`
+
+// ---------- Types ----------
+
+// Category type represents a category in a tree (self-referential many-to-one relationship)
+type Category struct {
+	Name     string      `sql:"Name" def:"VARCHAR(128)"`
+	Parent   *Category   `sql:"ParentID" rel:"many-to-one"`
+	Children []*Category `inverse:"Parent"`
+}
+
+func (r Category) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+	parents := map[int]int{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM Category")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id       int
+		name     string
+		parentID sql.NullInt64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &name, &parentID); err != nil {
+			return items, err
+		}
+
+		obj := Category{Name: name}
+		items[id] = &obj
+
+		// Parents are in the same table, so they are linked after every row is read
+		if parentID.Valid {
+			parents[id] = int(parentID.Int64)
+		}
+	}
+
+	// Link each category to its parent
+	for id, parentID := range parents {
+		parent, ok := items[parentID]
+		if !ok {
+			return items, fmt.Errorf("parent category %v is not in the table", parentID)
+		}
+		items[id].(*Category).Parent = parent.(*Category)
+	}
+
+	return items, nil
+}
+
+// ---------- Methods ----------
+
+func printCategory(category *Category, depth int) {
+	id, err := categoryWrapper.GetID(category)
+	if err != nil {
+		fmt.Printf("Error getting category ID: %v\n", err.Error())
+		return
+	}
+	fmt.Printf("%v%v (%v)\n", strings.Repeat("  ", depth), category.Name, id)
+
+	// Print the children below the category
+	for _, child := range category.Children {
+		printCategory(child, depth+1)
+	}
+}
+
+func getCategories() {
+	// Get the categories
+	categories, err := categoryWrapper.Get()
+	if err != nil {
+		fmt.Printf("Error in receiving categories from SQL: %v\n", err.Error())
+	}
+
+	// Print each tree starting from the categories without a parent
+	ids := []int{}
+	for id, v := range categories {
+		if v.Parent == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		printCategory(categories[id], 0)
+	}
+}
+
+func getParent(prompt string) (*Category, bool) {
+	fmt.Print(prompt)
+	scanner.Scan()
+
+	// An empty ID means the category has no parent
+	if scanner.Text() == "" {
+		return nil, true
+	}
+
+	parentID, err := strconv.ParseInt(scanner.Text(), 10, 0)
+	if err != nil {
+		fmt.Println("Error reading input, please try again")
+		return nil, false
+	}
+
+	parent, err := categoryWrapper.GetByID(int(parentID))
+	if err != nil {
+		fmt.Printf("Error getting category with ID: %v\n", err.Error())
+		return nil, false
+	}
+
+	return parent, true
+}
+
+func addCategory() {
+	category := Category{}
+
+	// Get the name of the category
+	fmt.Print("Enter category name: ")
+	scanner.Scan()
+
+	category.Name = scanner.Text()
+
+	// Get the parent of the category
+	parent, ok := getParent("Enter parent ID (empty for none): ")
+	if !ok {
+		return
+	}
+	category.Parent = parent
+
+	// Add the category to the wrapper
+	if err := categoryWrapper.Save(&category); err != nil {
+		fmt.Printf("Error saving category: %v\n", err.Error())
+	} else {
+		fmt.Println("Category saved successfully")
+	}
+}
+
+func moveCategory() {
+	// Get the category ID to move
+	fmt.Print("Enter category ID to move: ")
+	scanner.Scan()
+
+	moveID, err := strconv.ParseInt(scanner.Text(), 10, 0)
+	if err != nil {
+		fmt.Println("Error reading input, please try again")
+		return
+	}
+
+	category, err := categoryWrapper.GetByID(int(moveID))
+	if err != nil {
+		fmt.Printf("Error getting category with ID: %v\n", err.Error())
+		return
+	}
+
+	// Get the new parent of the category
+	parent, ok := getParent("Enter new parent ID (empty for none): ")
+	if !ok {
+		return
+	}
+	category.Parent = parent
+
+	// Save the category
+	if err = categoryWrapper.Save(category); err != nil {
+		fmt.Printf("Error moving category: %v\n", err.Error())
+	} else {
+		fmt.Println("Category moved successfully")
+	}
+}
+
+func deleteCategory() {
+	// Get the category ID to delete
+	fmt.Print("Enter category ID to delete: ")
+	scanner.Scan()
+
+	deleteID, err := strconv.ParseInt(scanner.Text(), 10, 0)
+	if err != nil {
+		fmt.Println("Error reading input, please try again")
+		return
+	}
+
+	category, err := categoryWrapper.GetByID(int(deleteID))
+	if err != nil {
+		fmt.Printf("Error getting category with ID: %v\n", err.Error())
+		return
+	}
+
+	// Delete the category (its children are deleted with it)
+	if err = categoryWrapper.Delete(category); err != nil {
+		fmt.Printf("Error deleting category: %v\n", err.Error())
+	} else {
+		fmt.Println("Category deleted successfully")
+	}
+}
+
+// ---------- Globals ----------
+var scanner *bufio.Scanner
+var categoryWrapper *sql_wrapper.Wrapper[*Category]
+
+// ---------- main -----------
+
+func main() {
+	// Open SQL database
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	scanner = bufio.NewScanner(os.Stdin)
+
+	// Create new wrappers
+	categoryWrapper, err = sql_wrapper.NewWrapper[*Category](db, Category{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Read in information from the wrappers
+	if err := categoryWrapper.Read(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Read user input
+	for {
+		fmt.Print(AskPrompt)
+		scanner.Scan()
+
+		text := scanner.Text()
+
+		// Break if user enters empty string
+		if len(text) == 0 {
+			break
+		}
+
+		fmt.Println()
+
+		switch text {
+		case "1":
+			getCategories()
+
+		case "2":
+			addCategory()
+
+		case "3":
+			moveCategory()
+
+		case "4":
+			deleteCategory()
+
+		default:
+			continue
+		}
+
+		fmt.Println()
+	}
+
+	// handle error
+	if scanner.Err() != nil {
+		fmt.Println("Error: ", scanner.Err())
+	}
+}
@@ -59,6 +59,8 @@ type Post struct {
 	Likes   int      `sql:"Likes" def:"INT"`
 	Type    PostType `sql:"Type" def:"ENUM('Original', 'Comment', 'Repost')"`
 
+	Author *User `inverse:"Posts"`
+
 	Temporary string `sql:"-"`
 }
 
@@ -94,7 +96,7 @@ func (r Post) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
 // User type represents a user in the system (one-to-many relationship)
 type User struct {
 	Name  string  `sql:"Name" def:"VARCHAR(128)"`
-	Posts []*Post `sql:"PostID" rel:"one-to-many"`
+	Posts []*Post `sql:"PostID" rel:"one-to-many" ordered:""`
 }
 
 func (r User) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
@@ -122,7 +124,7 @@ func (r User) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
 	}
 
 	// Query the related elements
-	rows, err = db.Query("SELECT * FROM UserPost")
+	rows, err = db.Query("SELECT UserID, PostID FROM UserPosts")
 	if err != nil {
 		return items, err
 	}
@@ -173,10 +175,14 @@ func getPosts() {
 	}
 
 	// Print the posts
-	fmt.Println("    ID    |  Message  |   Likes   |   Type   ")
-	fmt.Println("--------------------------------------------")
+	fmt.Println("    ID    |  Message  |   Likes   |   Type   |  Author  ")
+	fmt.Println("-------------------------------------------------------")
 	for id, v := range posts {
-		fmt.Printf("%-10v|%-11v|%-11v|%-10v\n", id, v.Message, v.Likes, v.Type)
+		author := ""
+		if v.Author != nil {
+			author = v.Author.Name
+		}
+		fmt.Printf("%-10v|%-11v|%-11v|%-10v|%-10v\n", id, v.Message, v.Likes, v.Type, author)
 	}
 }
 
@@ -0,0 +1,97 @@
+package sql_wrapper
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// isNumber is a helper method that checks if a kind is an integer or floating point number
+func isNumber(kind reflect.Kind) bool {
+	return isInteger(kind) || kind == reflect.Float32 || kind == reflect.Float64
+}
+
+// isInteger is a helper method that checks if a kind is a signed or unsigned integer
+func isInteger(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// updateExpr sets a field of an entry to the result of an SQL expression and reads the result back into the field.
+// The arguments fill the placeholders of the expression
+func (s *schema) updateExpr(tx *Tx, val Readable, name string, expr string, args []interface{}) error {
+	obj, err := s.validate(val)
+	if err != nil {
+		return err
+	}
+
+	if obj.GetID() < 0 {
+		return fmt.Errorf("object does not have valid id")
+	}
+
+	p, err := s.storedPlan(name)
+	if err != nil {
+		return err
+	} else if p.rel != UndefinedRelationType {
+		return fmt.Errorf("field '%v' is a relation and cannot be set by an expression", name)
+	}
+
+	// Start a transaction in the database
+	t, err := s.begin(tx)
+	if err != nil {
+		return err
+	}
+
+	// Rollback the transaction if there is an error
+	defer func() {
+		if err != nil {
+			t.Rollback()
+		}
+	}()
+
+	if _, err = t.Exec(fmt.Sprintf("UPDATE %v SET %v = %v WHERE id = %v;", s.table, p.name, expr, obj.GetID()), args...); err != nil {
+		return err
+	}
+
+	// Read the result while the row is still locked by the update
+	result := reflect.New(p.field.Type)
+	if err = t.QueryRow(fmt.Sprintf("SELECT %v FROM %v WHERE id = %v;", p.name, s.table, obj.GetID())).Scan(result.Interface()); err != nil {
+		return err
+	}
+
+	if err = t.Commit(); err != nil {
+		return err
+	}
+
+	tx.touch(s, obj.GetID())
+	tx.recordField(obj.Object(), p.index)
+
+	reflect.ValueOf(obj.Object()).Elem().Field(p.index).Set(result.Elem())
+	s.persistField(obj.GetID(), p.index)
+
+	// Update inverse fields that mirror the schema
+	manager.syncInverses(s, obj.Object())
+	return nil
+}
+
+// increment atomically adds to a number field of an entry and reads the result back into the field
+func (s *schema) increment(tx *Tx, val Readable, name string, delta interface{}) error {
+	p, err := s.storedPlan(name)
+	if err != nil {
+		return err
+	} else if p.rel != UndefinedRelationType || !isNumber(p.field.Type.Kind()) {
+		return fmt.Errorf("field '%v' is not a number", name)
+	}
+
+	// Integer fields cannot hold the fractional result of a floating point delta
+	if v := reflect.ValueOf(delta); !v.IsValid() || !isNumber(v.Kind()) {
+		return fmt.Errorf("cannot increment field '%v' by value of type %T", name, delta)
+	} else if isInteger(p.field.Type.Kind()) && !isInteger(v.Kind()) {
+		return fmt.Errorf("cannot increment integer field '%v' by value of type %T", name, delta)
+	}
+
+	return s.updateExpr(tx, val, name, p.name+" + ?", []interface{}{delta})
+}
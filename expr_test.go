@@ -0,0 +1,54 @@
+package sql_wrapper_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Tests ----------
+
+func TestIncrement(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	obj := TestObject{Name: "Jack", Age: 20, Weather: Summer}
+	id, err := wrapper.Insert(&obj)
+	assert.Nil(err)
+
+	// Change the column behind the wrapper's back
+	_, err = database.Exec("UPDATE TestObject SET Age = 30 WHERE id = ?", id)
+	assert.Nil(err)
+
+	// The increment applies to the value in the database and refreshes the field
+	assert.Nil(wrapper.Increment(&obj, "Age", 1))
+	assert.Equal(31, obj.Age)
+
+	changes, err := wrapper.Changes(&obj)
+	assert.Nil(err)
+	assert.Empty(changes)
+
+	// Only number fields can be incremented by numbers
+	assert.NotNil(wrapper.Increment(&obj, "Name", 1))
+	assert.NotNil(wrapper.Increment(&obj, "Age", "1"))
+	assert.NotNil(wrapper.Increment(&obj, "Age", 1.5))
+}
+
+func TestUpdateExpr(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	obj := TestObject{Name: "Jack", Age: 20, Weather: Summer}
+	_, err := wrapper.Insert(&obj)
+	assert.Nil(err)
+
+	assert.Nil(wrapper.UpdateExpr(&obj, "Name", "CONCAT(Name, ?)", " Smith"))
+	assert.Equal("Jack Smith", obj.Name)
+
+	assert.Nil(wrapper.UpdateExpr(&obj, "Age", "Age * 2"))
+	assert.Equal(40, obj.Age)
+
+	// Invalid expressions leave the field unchanged
+	assert.NotNil(wrapper.UpdateExpr(&obj, "Age", "Missing + 1"))
+	assert.Equal(40, obj.Age)
+}
@@ -0,0 +1,184 @@
+package sql_wrapper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// dependencies returns the names of the schemas a schema references through relation fields, excluding itself.
+// Lazy relations do not need their targets to be read first
+func (m *schemaManager) dependencies(s *schema) []string {
+	names := []string{}
+	seen := map[string]bool{s.name(): true}
+
+	for _, p := range s.plan {
+		if p.skip || p.rel == UndefinedRelationType || p.lazy {
+			continue
+		}
+
+		if _, ok := m.schemas[p.target]; ok && !seen[p.target] {
+			seen[p.target] = true
+			names = append(names, p.target)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// readOrder groups the schemas into components that reference each other in a cycle. Components are ordered so
+// every component comes after the components it references
+func (m *schemaManager) readOrder() [][]*schema {
+	names := make([]string, 0, len(m.schemas))
+	for name := range m.schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Find the strongly connected components with Tarjan's algorithm, which finds a component after the
+	// components it references
+	components := [][]*schema{}
+	index := map[string]int{}
+	lowlink := map[string]int{}
+	onStack := map[string]bool{}
+	stack := []string{}
+
+	var visit func(name string)
+	visit = func(name string) {
+		index[name] = len(index)
+		lowlink[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+
+		for _, dep := range m.dependencies(m.schemas[name]) {
+			if _, ok := index[dep]; !ok {
+				visit(dep)
+				if lowlink[dep] < lowlink[name] {
+					lowlink[name] = lowlink[dep]
+				}
+			} else if onStack[dep] && index[dep] < lowlink[name] {
+				lowlink[name] = index[dep]
+			}
+		}
+
+		if lowlink[name] != index[name] {
+			return
+		}
+
+		// Pop the component off the stack
+		component := []*schema{}
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+
+			component = append(component, m.schemas[top])
+			if top == name {
+				break
+			}
+		}
+
+		sort.Slice(component, func(i, j int) bool { return component[i].name() < component[j].name() })
+		components = append(components, component)
+	}
+
+	for _, name := range names {
+		if _, ok := index[name]; !ok {
+			visit(name)
+		}
+	}
+
+	return components
+}
+
+// readAll reads every schema so that referenced schemas are read first. Schemas that reference each other in a
+// cycle are read in two phases: their rows are read first and their relation fields are linked afterwards
+func (m *schemaManager) readAll() error {
+	for _, component := range m.readOrder() {
+		if len(component) == 1 {
+			if err := component[0].load(nil); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// References to schemas in the component resolve to placeholders until every schema is read
+		m.deferred = map[string]bool{}
+		m.placeholders = map[placeholder]Readable{}
+		for _, s := range component {
+			m.deferred[s.name()] = true
+		}
+
+		err := m.loadComponent(component)
+		m.deferred = nil
+		m.placeholders = nil
+		if err != nil {
+			return err
+		}
+	}
+
+	// Set the IDs of lazy relations and restore the order of ordered relations
+	for _, s := range m.schemas {
+		if err := s.fillLazy(nil); err != nil {
+			return err
+		}
+		s.loaded = true
+
+		if err := s.orderLinks(nil); err != nil {
+			return err
+		}
+	}
+
+	// Record the state of the objects once every relation is linked
+	for _, s := range m.schemas {
+		s.persistAll()
+	}
+
+	// Update inverse fields that mirror the schemas
+	return m.refreshInverses()
+}
+
+// loadComponent reads the schemas of a component that reference each other in a cycle and replaces the placeholders
+// their Read methods were given with the objects that were read. Placeholders of rows that do not exist are reported
+func (m *schemaManager) loadComponent(component []*schema) error {
+	for _, s := range component {
+		if err := s.load(nil); err != nil {
+			return err
+		}
+	}
+
+	// Map the placeholders to the objects that were read
+	replaced := map[Readable]Readable{}
+	missing := map[string][]int{}
+	for key, obj := range m.placeholders {
+		linked, err := key.schema.getByID(key.id)
+		if err != nil {
+			missing[key.schema.name()] = append(missing[key.schema.name()], key.id)
+			continue
+		}
+		replaced[obj] = linked
+	}
+
+	if len(missing) > 0 {
+		strs := []string{}
+		for name, ids := range missing {
+			sort.Ints(ids)
+			strs = append(strs, fmt.Sprintf("%v in schema '%v'", ids, name))
+		}
+		sort.Strings(strs)
+
+		return fmt.Errorf("objects %v are referenced but were not read", strings.Join(strs, ", "))
+	}
+
+	// Replace the placeholders in the relation fields of every object in the component
+	for _, s := range component {
+		for _, obj := range s.objects {
+			for _, target := range component {
+				s.replaceTargets(nil, obj.Object(), target.name(), replaced)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package sql_wrapper_test
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"testing"
+
+	sql_wrapper "github.com/ethanbaker/sql-wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Types ----------
+
+// Team is used to test reading schemas that reference each other
+type Team struct {
+	Name    string  `sql:"Name" def:"VARCHAR(128)"`
+	Captain *Player `sql:"CaptainID" rel:"one-to-one" ondelete:"set null"`
+}
+
+// Read reads in Teams from an SQL query
+func (t Team) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM Team")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id        int
+		name      string
+		captainID sql.NullInt64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &name, &captainID); err != nil {
+			return items, err
+		}
+
+		obj := Team{Name: name}
+
+		// Get the referenced captain from another schema
+		if captainID.Valid {
+			readable, err := sql_wrapper.GetObjectBySchema("Player", int(captainID.Int64))
+			if err != nil {
+				return items, err
+			}
+			captain, ok := readable.(*Player)
+			if !ok {
+				return items, fmt.Errorf("cannot cast object to *Player")
+			}
+			obj.Captain = captain
+		}
+
+		items[id] = &obj
+	}
+
+	return items, nil
+}
+
+// Player is used to test reading schemas that reference each other
+type Player struct {
+	Name string `sql:"Name" def:"VARCHAR(128)"`
+	Team *Team  `sql:"TeamID" rel:"many-to-one"`
+}
+
+// Read reads in Players from an SQL query
+func (p Player) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM Player")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id     int
+		name   string
+		teamID sql.NullInt64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &name, &teamID); err != nil {
+			return items, err
+		}
+
+		obj := Player{Name: name}
+
+		// Get the referenced team from another schema
+		if teamID.Valid {
+			readable, err := sql_wrapper.GetObjectBySchema("Team", int(teamID.Int64))
+			if err != nil {
+				return items, err
+			}
+			team, ok := readable.(*Team)
+			if !ok {
+				return items, fmt.Errorf("cannot cast object to *Team")
+			}
+			obj.Team = team
+		}
+
+		items[id] = &obj
+	}
+
+	return items, nil
+}
+
+// ---------- Globals ----------
+
+var teamWrapper *sql_wrapper.Wrapper[*Team]
+var playerWrapper *sql_wrapper.Wrapper[*Player]
+
+// ---------- Tests ----------
+
+func TestReadAllOrder(t *testing.T) {
+	graphSetup()
+	assert := assert.New(t)
+
+	book := Book{Title: "First"}
+	author := Author{Name: "Jack", Books: []*Book{&book}}
+
+	// Insert the objects
+	bookID, err := bookWrapper.Insert(&book)
+	assert.Nil(err)
+
+	authorID, err := authorWrapper.Insert(&author)
+	assert.Nil(err)
+
+	// Create the wrappers with the referencing schema first
+	authorWrapper, err = sql_wrapper.NewWrapper[*Author](database, Author{})
+	assert.Nil(err)
+
+	bookWrapper, err = sql_wrapper.NewWrapper[*Book](database, Book{})
+	assert.Nil(err)
+
+	// Reading every schema should read the books before the authors
+	assert.Nil(sql_wrapper.ReadAll())
+
+	readAuthor, err := authorWrapper.GetByID(authorID)
+	assert.Nil(err)
+
+	readBook, err := bookWrapper.GetByID(bookID)
+	assert.Nil(err)
+
+	assert.Equal([]*Book{readBook}, readAuthor.Books)
+	assert.Equal(readAuthor, readBook.Author)
+}
+
+func TestReadAllCycle(t *testing.T) {
+	graphSetup()
+	assert := assert.New(t)
+
+	team := Team{Name: "Red"}
+	player := Player{Name: "Jack", Team: &team}
+
+	// Insert the objects and link them in both directions
+	teamID, err := teamWrapper.Insert(&team)
+	assert.Nil(err)
+
+	playerID, err := playerWrapper.Insert(&player)
+	assert.Nil(err)
+
+	team.Captain = &player
+	assert.Nil(teamWrapper.Update(&team))
+
+	// Read the objects into new wrappers
+	teamWrapper, err = sql_wrapper.NewWrapper[*Team](database, Team{})
+	assert.Nil(err)
+
+	playerWrapper, err = sql_wrapper.NewWrapper[*Player](database, Player{})
+	assert.Nil(err)
+
+	assert.Nil(sql_wrapper.ReadAll())
+
+	// Both references should be linked after the cycle is read
+	readTeam, err := teamWrapper.GetByID(teamID)
+	assert.Nil(err)
+
+	readPlayer, err := playerWrapper.GetByID(playerID)
+	assert.Nil(err)
+
+	assert.Equal(readPlayer, readTeam.Captain)
+	assert.Equal(readTeam, readPlayer.Team)
+}
+
+func TestReadAllCycleMissing(t *testing.T) {
+	graphSetup()
+	assert := assert.New(t)
+
+	team := Team{Name: "Red"}
+	_, err := teamWrapper.Insert(&team)
+	assert.Nil(err)
+
+	// Reference a player that does not exist, which the table allows since it has no foreign key to Player
+	_, err = database.Exec("UPDATE Team SET CaptainID = 999;")
+	assert.Nil(err)
+
+	teamWrapper, err = sql_wrapper.NewWrapper[*Team](database, Team{})
+	assert.Nil(err)
+
+	playerWrapper, err = sql_wrapper.NewWrapper[*Player](database, Player{})
+	assert.Nil(err)
+
+	// The missing player should be reported instead of leaving an empty captain
+	err = sql_wrapper.ReadAll()
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "[999] in schema 'Player'")
+}
+
+// ---------- Test Setup ----------
+
+func graphSetup() {
+	// Drop the current wrapper
+	dropTables()
+
+	// Create the tables of the cycle without the foreign key from Team to Player, which cannot be created before
+	// the Player table exists
+	for _, str := range []string{
+		"CREATE TABLE Team(id INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY, Name VARCHAR(128), CaptainID INT UNSIGNED UNIQUE);",
+		"CREATE TABLE Player(id INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY, Name VARCHAR(128), TeamID INT UNSIGNED, FOREIGN KEY (TeamID) REFERENCES Team(id) ON DELETE CASCADE ON UPDATE CASCADE);",
+	} {
+		if _, err := database.Exec(str); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Create a wrapper for every schema so every table read by ReadAll exists
+	for _, template := range []sql_wrapper.Readable{TestObject{}, ReferenceObject{}, ActionObject{}, ChainObject{}, Book{}, Author{}, Category{}, Member{}, Club{}, Person{}, Song{}, Playlist{}, Car{}, Garage{}, Writer{}, Article{}, Issue{}, IndexedObject{}} {
+		if _, err := sql_wrapper.NewWrapper[sql_wrapper.Readable](database, template); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Create the new wrappers
+	var err error
+	bookWrapper, err = sql_wrapper.NewWrapper[*Book](database, Book{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	authorWrapper, err = sql_wrapper.NewWrapper[*Author](database, Author{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	teamWrapper, err = sql_wrapper.NewWrapper[*Team](database, Team{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	playerWrapper, err = sql_wrapper.NewWrapper[*Player](database, Player{})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
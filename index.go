@@ -0,0 +1,222 @@
+package sql_wrapper
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultIndexPriority is the priority given to index columns without a 'priority' option
+const defaultIndexPriority = 10
+
+// indexComment is the comment that marks the indexes created by the wrapper
+const indexComment = "sql-wrapper"
+
+// Index represents an index or unique constraint declared on a schema
+type Index struct {
+	Name    string   // The name of the index
+	Columns []string // The columns in the index, ordered by priority
+	Unique  bool     // Whether the index is a unique constraint
+}
+
+// tableIndex is an index that exists on a table
+type tableIndex struct {
+	Index
+	managed bool // Whether the index was created by the wrapper
+}
+
+// indexColumn is a column that belongs to an index before it is sorted by priority
+type indexColumn struct {
+	name     string
+	priority int
+	order    int
+}
+
+// definitionSQL creates the definition of an index used inside of a CREATE TABLE statement
+func (i Index) definitionSQL() string {
+	if i.Unique {
+		return fmt.Sprintf("UNIQUE KEY %v (%v) COMMENT '%v'", i.Name, strings.Join(i.Columns, ", "), indexComment)
+	}
+	return fmt.Sprintf("INDEX %v (%v) COMMENT '%v'", i.Name, strings.Join(i.Columns, ", "), indexComment)
+}
+
+// equal checks if two indexes have the same columns and uniqueness
+func (i Index) equal(other Index) bool {
+	if i.Unique != other.Unique || len(i.Columns) != len(other.Columns) {
+		return false
+	}
+
+	for k := range i.Columns {
+		if i.Columns[k] != other.Columns[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// getIndexes is a helper method that builds the indexes declared by the 'index' and 'unique' tags of a struct
+func getIndexes(table string, t reflect.Type) ([]Index, error) {
+	indexes := []Index{}
+	columns := map[string][]indexColumn{}
+	unique := map[string]bool{}
+
+	for i, field := range reflect.VisibleFields(t) {
+		for _, tag := range []string{"index", "unique"} {
+			val, ok := field.Tag.Lookup(tag)
+			if !ok {
+				continue
+			}
+
+			// Get the name of the column
+			name, err := getName(field)
+			if err != nil {
+				return indexes, err
+			} else if name == "-" {
+				return indexes, fmt.Errorf("tag '%v' cannot be used on ignored field '%v'", tag, field.Name)
+			}
+
+			// Slice relations do not have a column in the table
+			rel := getRelation(field)
+			if rel == OneToMany || rel == ManyToMany {
+				return indexes, fmt.Errorf("tag '%v' cannot be used on field '%v' with a slice relation", tag, field.Name)
+			}
+
+			// Parse the index name and options
+			opts := strings.Split(val, ",")
+			indexName := strings.TrimSpace(opts[0])
+			if indexName == "" {
+				prefix := "idx"
+				if tag == "unique" {
+					prefix = "uniq"
+				}
+				indexName = fmt.Sprintf("%v_%v_%v", prefix, table, name)
+			}
+
+			priority := defaultIndexPriority
+			for _, opt := range opts[1:] {
+				key, value, _ := strings.Cut(strings.TrimSpace(opt), "=")
+				if key != "priority" {
+					return indexes, fmt.Errorf("unknown option '%v' in tag '%v' for field '%v'", key, tag, field.Name)
+				}
+
+				priority, err = strconv.Atoi(value)
+				if err != nil {
+					return indexes, fmt.Errorf("invalid priority '%v' in tag '%v' for field '%v'", value, tag, field.Name)
+				}
+			}
+
+			// Make sure an index is not declared as both unique and non-unique
+			isUnique, present := unique[indexName]
+			if present && isUnique != (tag == "unique") {
+				return indexes, fmt.Errorf("index '%v' is declared as both unique and non-unique", indexName)
+			} else if !present {
+				indexes = append(indexes, Index{Name: indexName, Unique: tag == "unique"})
+			}
+			unique[indexName] = tag == "unique"
+
+			columns[indexName] = append(columns[indexName], indexColumn{name: name, priority: priority, order: i})
+		}
+	}
+
+	// Sort the columns in each index by priority and add them to the index
+	for k := range indexes {
+		cols := columns[indexes[k].Name]
+		sort.SliceStable(cols, func(a, b int) bool {
+			if cols[a].priority != cols[b].priority {
+				return cols[a].priority < cols[b].priority
+			}
+			return cols[a].order < cols[b].order
+		})
+
+		for _, col := range cols {
+			indexes[k].Columns = append(indexes[k].Columns, col.name)
+		}
+	}
+
+	return indexes, nil
+}
+
+// readIndexes reads the indexes that currently exist on the schema's table. Indexes are managed by the wrapper if they
+// carry its comment
+func (s *schema) readIndexes(tx *sql.Tx) (map[string]tableIndex, error) {
+	existing := map[string]tableIndex{}
+
+	rows, err := tx.Query("SELECT INDEX_NAME, NON_UNIQUE, COLUMN_NAME, INDEX_COMMENT FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY INDEX_NAME, SEQ_IN_INDEX;", s.table)
+	if err != nil {
+		return existing, err
+	}
+	defer rows.Close()
+
+	var (
+		name      string
+		nonUnique int
+		column    string
+		comment   string
+	)
+	for rows.Next() {
+		if err := rows.Scan(&name, &nonUnique, &column, &comment); err != nil {
+			return existing, err
+		}
+
+		index := existing[name]
+		index.Name = name
+		index.Unique = nonUnique == 0
+		index.Columns = append(index.Columns, column)
+		index.managed = comment == indexComment
+		existing[name] = index
+	}
+
+	return existing, rows.Err()
+}
+
+// migrateIndexesSQL creates strings that will add or change declared indexes that differ from the existing ones and
+// drop the indexes managed by the wrapper that are no longer declared
+func (s *schema) migrateIndexesSQL(existing map[string]tableIndex) []string {
+	statements := []string{}
+
+	declared := map[string]bool{}
+	for _, index := range s.indexes {
+		declared[index.Name] = true
+	}
+
+	// Foreign keys need an index on their column, so indexes that start with one are kept
+	references := map[string]bool{}
+	for _, p := range s.plan {
+		if !p.skip && p.pointer() {
+			references[p.name] = true
+		}
+	}
+
+	names := make([]string, 0, len(existing))
+	for name := range existing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		old := existing[name]
+		if !old.managed || declared[name] || references[old.Columns[0]] {
+			continue
+		}
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %v DROP INDEX %v;", s.table, name))
+	}
+
+	for _, index := range s.indexes {
+		// Indexes that are not managed yet are added again so they carry the comment
+		old, ok := existing[index.Name]
+		if ok && old.managed && old.Index.equal(index) {
+			continue
+		}
+
+		// Drop the outdated index before adding the new definition
+		if ok {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %v DROP INDEX %v;", s.table, index.Name))
+		}
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %v ADD %v;", s.table, index.definitionSQL()))
+	}
+
+	return statements
+}
@@ -0,0 +1,168 @@
+package sql_wrapper_test
+
+import (
+	"database/sql"
+	"log"
+	"testing"
+
+	sql_wrapper "github.com/ethanbaker/sql-wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Types ----------
+
+// IndexedObject is used to test index and unique constraint tags
+type IndexedObject struct {
+	Author   string `sql:"Author" def:"VARCHAR(128)" index:"idx_author_type,priority=2"`
+	Type     string `sql:"Type" def:"VARCHAR(32)" index:"idx_author_type,priority=1"`
+	Email    string `sql:"Email" def:"VARCHAR(128)" unique:""`
+	Priority int    `sql:"Priority" def:"INT" index:""`
+}
+
+// Read reads in IndexedObjects from an SQL query
+func (t IndexedObject) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM IndexedObject")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id       int
+		author   string
+		kind     string
+		email    string
+		priority int
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &author, &kind, &email, &priority); err != nil {
+			return items, err
+		}
+
+		obj := IndexedObject{Author: author, Type: kind, Email: email, Priority: priority}
+		items[id] = &obj
+	}
+
+	return items, nil
+}
+
+// ---------- Globals ----------
+
+var indexedWrapper *sql_wrapper.Wrapper[*IndexedObject]
+
+// ---------- Tests ----------
+
+func TestIndexes(t *testing.T) {
+	indexSetup()
+	assert := assert.New(t)
+
+	// The wrapper should expose the declared indexes
+	indexes := indexedWrapper.Indexes()
+	assert.Equal([]sql_wrapper.Index{
+		{Name: "idx_author_type", Columns: []string{"Type", "Author"}},
+		{Name: "uniq_IndexedObject_Email", Columns: []string{"Email"}, Unique: true},
+		{Name: "idx_IndexedObject_Priority", Columns: []string{"Priority"}},
+	}, indexes)
+
+	// Changing the returned indexes should not change the declared ones
+	indexes[0].Columns[0] = "Email"
+	assert.Equal([]string{"Type", "Author"}, indexedWrapper.Indexes()[0].Columns)
+
+	// The indexes should be present in the database
+	rows, err := database.Query("SELECT INDEX_NAME, NON_UNIQUE, COLUMN_NAME FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'IndexedObject' AND INDEX_NAME = 'idx_author_type' ORDER BY SEQ_IN_INDEX")
+	assert.Nil(err)
+	defer rows.Close()
+
+	var (
+		name      string
+		nonUnique int
+		column    string
+	)
+	assert.True(rows.Next())
+	assert.Nil(rows.Scan(&name, &nonUnique, &column))
+	assert.Equal(1, nonUnique)
+	assert.Equal("Type", column)
+
+	assert.True(rows.Next())
+	assert.Nil(rows.Scan(&name, &nonUnique, &column))
+	assert.Equal("Author", column)
+
+	assert.False(rows.Next())
+	assert.Nil(rows.Err())
+}
+
+func TestUniqueConstraint(t *testing.T) {
+	indexSetup()
+	assert := assert.New(t)
+
+	obj1 := IndexedObject{Author: "Jack", Type: "Original", Email: "jack@example.com", Priority: 1}
+	obj2 := IndexedObject{Author: "John", Type: "Comment", Email: "jack@example.com", Priority: 2}
+
+	// Inserting the first object should succeed
+	_, err := indexedWrapper.Insert(&obj1)
+	assert.Nil(err)
+
+	// Inserting an object with the same email should fail
+	_, err = indexedWrapper.Insert(&obj2)
+	assert.NotNil(err)
+}
+
+func TestIndexMigration(t *testing.T) {
+	indexSetup()
+	assert := assert.New(t)
+
+	// Drop a declared index to simulate a table created before the tag was added
+	_, err := database.Exec("ALTER TABLE IndexedObject DROP INDEX idx_author_type")
+	assert.Nil(err)
+
+	// Creating a new wrapper should add the index back
+	_, err = sql_wrapper.NewWrapper[*IndexedObject](database, IndexedObject{})
+	assert.Nil(err)
+
+	var count int
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'IndexedObject' AND INDEX_NAME = 'idx_author_type'").Scan(&count))
+	assert.Equal(2, count)
+}
+
+func TestIndexRemoval(t *testing.T) {
+	indexSetup()
+	assert := assert.New(t)
+
+	// Add an index the wrapper created for a tag that was removed, and an index the wrapper does not manage
+	_, err := database.Exec("ALTER TABLE IndexedObject ADD INDEX idx_removed (Author) COMMENT 'sql-wrapper'")
+	assert.Nil(err)
+	_, err = database.Exec("ALTER TABLE IndexedObject ADD INDEX idx_manual (Author)")
+	assert.Nil(err)
+	_, err = database.Exec("ALTER TABLE IndexedObject ADD INDEX idx_IndexedObject_Author (Author)")
+	assert.Nil(err)
+
+	// Creating a new wrapper should only drop the index that is no longer declared
+	_, err = sql_wrapper.NewWrapper[*IndexedObject](database, IndexedObject{})
+	assert.Nil(err)
+
+	var count int
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'IndexedObject' AND INDEX_NAME = 'idx_removed'").Scan(&count))
+	assert.Equal(0, count)
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'IndexedObject' AND INDEX_NAME IN ('idx_manual', 'idx_IndexedObject_Author')").Scan(&count))
+	assert.Equal(2, count)
+}
+
+// ---------- Test Setup ----------
+
+func indexSetup() {
+	// Drop the current table
+	_, err := database.Exec("DROP TABLE IF EXISTS IndexedObject;")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Create the new wrapper
+	indexedWrapper, err = sql_wrapper.NewWrapper[*IndexedObject](database, IndexedObject{})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
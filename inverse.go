@@ -0,0 +1,436 @@
+package sql_wrapper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// inverse is a field in one schema that mirrors a relation field in another schema
+type inverse struct {
+	holder      *schema // The schema that holds the inverse field
+	index       int     // The index of the inverse field in the holder struct
+	owner       *schema // The schema that owns the relation field
+	ownerIndex  int     // The index of the relation field in the owner struct
+	ownerIsList bool    // Whether the relation field is a slice
+	links       *links  // The owners listed in the inverse field of each holder object
+}
+
+// links tracks which owners are listed in the inverse fields of which holder objects, so a write only updates the
+// holder objects it affects
+type links struct {
+	targets map[Readable][]Readable // The holder objects each owner is listed in
+	owners  map[Readable][]Readable // The owners listed in each holder object, in order of ID
+}
+
+// linkInverses finds the inverse fields whose owners are registered, after validating they match the relation fields
+// they mirror
+func (m *schemaManager) linkInverses() error {
+	invs := []inverse{}
+
+	for _, holder := range m.schemas {
+		for _, p := range holder.plan {
+			if p.inverse == "" {
+				continue
+			}
+
+			// Skip inverses whose owner has not been registered yet
+			owner, ok := m.schemas[p.target]
+			if !ok {
+				continue
+			}
+
+			inv, err := newInverse(holder, p, owner)
+			if err != nil {
+				return err
+			}
+			inv.refresh()
+			invs = append(invs, inv)
+		}
+	}
+
+	m.invs = invs
+	return nil
+}
+
+// inverses returns the inverse fields that involve any of the given schemas (or every schema if none are given)
+func (m *schemaManager) inverses(schemas ...*schema) []inverse {
+	if len(schemas) == 0 {
+		return m.invs
+	}
+
+	invs := []inverse{}
+	for _, inv := range m.invs {
+		for _, s := range schemas {
+			if s == inv.holder || s == inv.owner {
+				invs = append(invs, inv)
+				break
+			}
+		}
+	}
+
+	return invs
+}
+
+// refreshInverses recomputes inverse fields from their owners for the given schemas (or every schema if none are given)
+func (m *schemaManager) refreshInverses(schemas ...*schema) error {
+	for _, inv := range m.inverses(schemas...) {
+		inv.refresh()
+	}
+	return nil
+}
+
+// syncInverses updates the inverse fields affected by objects of a schema that were written, read or removed. Only the
+// holder objects the objects are or were listed in are changed
+func (m *schemaManager) syncInverses(s *schema, objs ...Readable) {
+	for _, inv := range m.inverses(s) {
+		for _, obj := range objs {
+			if inv.owner == s {
+				inv.sync(obj)
+			}
+			if inv.holder == s {
+				inv.set(obj)
+			}
+		}
+	}
+}
+
+// syncSteps is a helper method that updates the inverse fields affected by saved objects
+func (m *schemaManager) syncSteps(steps []saveStep) {
+	for _, step := range steps {
+		m.syncInverses(step.schema, step.object)
+	}
+}
+
+// refresh recomputes the inverse field of every object in the holder schema
+func (inv inverse) refresh() {
+	// Reset the inverse field of every holder object
+	for _, obj := range inv.holder.objects {
+		field := reflect.ValueOf(obj.Object()).Elem().Field(inv.index)
+		field.Set(reflect.Zero(field.Type()))
+	}
+	inv.links.targets = map[Readable][]Readable{}
+	inv.links.owners = map[Readable][]Readable{}
+
+	// Add each owner to the inverse field of the objects it references, in order of ID
+	ids := make([]int, 0, len(inv.owner.objects))
+	for id := range inv.owner.objects {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		owner := inv.owner.objects[id].Object()
+		for _, target := range inv.targets(owner) {
+			inv.links.targets[owner] = append(inv.links.targets[owner], target)
+			inv.links.owners[target] = append(inv.links.owners[target], owner)
+		}
+	}
+
+	for target := range inv.links.owners {
+		inv.set(target)
+	}
+}
+
+// sync moves an owner to the inverse fields of the holder objects it references now. Owners that are no longer in
+// the owner schema are removed from every inverse field
+func (inv inverse) sync(owner Readable) {
+	old := inv.links.targets[owner]
+
+	current := []Readable{}
+	if _, err := inv.owner.getID(owner); err == nil {
+		current = inv.targets(owner)
+	}
+
+	changed := map[Readable]bool{}
+	for _, target := range old {
+		if !containsObject(current, target) {
+			inv.links.owners[target] = removeObject(inv.links.owners[target], owner)
+			changed[target] = true
+		}
+	}
+
+	for _, target := range current {
+		if !containsObject(old, target) {
+			inv.links.owners[target] = inv.insertOwner(inv.links.owners[target], owner)
+			changed[target] = true
+		}
+	}
+
+	if len(current) == 0 {
+		delete(inv.links.targets, owner)
+	} else {
+		inv.links.targets[owner] = current
+	}
+
+	for target := range changed {
+		if len(inv.links.owners[target]) == 0 {
+			delete(inv.links.owners, target)
+		}
+		inv.set(target)
+	}
+}
+
+// set sets the inverse field of a holder object to the owners that reference it. Objects that are no longer in the
+// holder schema stop being tracked
+func (inv inverse) set(holder Readable) {
+	if _, err := inv.holder.getID(holder); err != nil {
+		delete(inv.links.owners, holder)
+	}
+
+	owners := inv.links.owners[holder]
+	field := reflect.ValueOf(holder).Elem().Field(inv.index)
+	if field.Kind() != reflect.Slice {
+		if len(owners) == 0 {
+			field.Set(reflect.Zero(field.Type()))
+		} else {
+			field.Set(reflect.ValueOf(owners[0]))
+		}
+		return
+	}
+
+	slice := reflect.MakeSlice(field.Type(), 0, len(owners))
+	for _, owner := range owners {
+		slice = reflect.Append(slice, reflect.ValueOf(owner))
+	}
+	field.Set(slice)
+}
+
+// insertOwner is a helper method that adds an owner to a list of owners kept in order of ID
+func (inv inverse) insertOwner(owners []Readable, owner Readable) []Readable {
+	id, _ := inv.owner.getID(owner)
+	i := sort.Search(len(owners), func(i int) bool {
+		other, _ := inv.owner.getID(owners[i])
+		return other > id
+	})
+
+	owners = append(owners, nil)
+	copy(owners[i+1:], owners[i:])
+	owners[i] = owner
+	return owners
+}
+
+// containsObject is a helper method that checks if a list of objects contains an object
+func containsObject(objs []Readable, obj Readable) bool {
+	for _, o := range objs {
+		if o == obj {
+			return true
+		}
+	}
+	return false
+}
+
+// removeObject is a helper method that removes an object from a list of objects
+func removeObject(objs []Readable, obj Readable) []Readable {
+	kept := make([]Readable, 0, len(objs))
+	for _, o := range objs {
+		if o != obj {
+			kept = append(kept, o)
+		}
+	}
+	return kept
+}
+
+// targets returns the objects referenced by the relation field of an owner
+func (inv inverse) targets(owner Readable) []Readable {
+	targets := []Readable{}
+
+	field := reflect.ValueOf(owner).Elem().Field(inv.ownerIndex)
+	if !inv.ownerIsList {
+		if !field.IsNil() {
+			targets = append(targets, field.Interface().(Readable))
+		}
+		return targets
+	}
+
+	for i := 0; i < field.Len(); i++ {
+		if !field.Index(i).IsNil() {
+			targets = append(targets, field.Index(i).Interface().(Readable))
+		}
+	}
+	return targets
+}
+
+// owners returns the owners listed in the inverse field of a holder object
+func (inv inverse) owners(val Readable) []Readable {
+	owners := []Readable{}
+
+	field := reflect.ValueOf(val).Elem().Field(inv.index)
+	if field.Kind() != reflect.Slice {
+		if !field.IsNil() {
+			owners = append(owners, field.Interface().(Readable))
+		}
+		return owners
+	}
+
+	for i := 0; i < field.Len(); i++ {
+		if !field.Index(i).IsNil() {
+			owners = append(owners, field.Index(i).Interface().(Readable))
+		}
+	}
+	return owners
+}
+
+// push changes the relation field of owners to match the inverse field of holder objects and returns the changed
+// owners, together with the values their relation field had before. The owners are restored if a holder object
+// lists an owner that is not in the owner schema
+func (inv inverse) push(tx *Tx, vals []Readable) ([]saveStep, error) {
+	owners := []saveStep{}
+	changed := map[Readable]bool{}
+
+	for _, val := range vals {
+		// Get the owners the holder object should be referenced by
+		wanted := map[Readable]bool{}
+		for _, owner := range inv.owners(val) {
+			if _, err := inv.owner.validate(owner); err != nil {
+				restoreSteps(owners)
+				return nil, err
+			}
+			wanted[owner] = true
+		}
+
+		// Only the owners that reference the holder object now or should reference it can change
+		candidates := append([]Readable{}, inv.links.owners[val]...)
+		for _, owner := range inv.owners(val) {
+			if !containsObject(candidates, owner) {
+				candidates = append(candidates, owner)
+			}
+		}
+
+		for _, owner := range candidates {
+			id, err := inv.owner.getID(owner)
+			if err != nil {
+				continue
+			}
+
+			// Determine if the owner currently references the holder object
+			present := containsObject(inv.targets(owner), val)
+			if present == wanted[owner] {
+				continue
+			}
+
+			// Add or remove the holder object from the owner's relation field, remembering the value it had first
+			tx.touch(inv.owner, id)
+			tx.recordField(owner, inv.ownerIndex)
+
+			field := reflect.ValueOf(owner).Elem().Field(inv.ownerIndex)
+			if !changed[owner] {
+				changed[owner] = true
+				owners = append(owners, saveStep{schema: inv.owner, object: owner, index: inv.ownerIndex, old: reflect.ValueOf(field.Interface())})
+			}
+
+			if !inv.ownerIsList {
+				if wanted[owner] {
+					field.Set(reflect.ValueOf(val))
+				} else {
+					field.Set(reflect.Zero(field.Type()))
+				}
+			} else if wanted[owner] {
+				field.Set(reflect.Append(field, reflect.ValueOf(val)))
+			} else {
+				pruned := reflect.MakeSlice(field.Type(), 0, field.Len())
+				for i := 0; i < field.Len(); i++ {
+					if field.Index(i).Interface() != val {
+						pruned = reflect.Append(pruned, field.Index(i))
+					}
+				}
+				field.Set(pruned)
+			}
+		}
+	}
+
+	return owners, nil
+}
+
+// pushInversesSQL returns the statements that save owners changed by the inverse fields of holder objects, together
+// with the owners, which are persisted once the statements are committed or restored with restoreSteps if they fail.
+// Every changed owner is saved by one update, however many holder objects or inverse fields changed it
+func (s *schema) pushInversesSQL(tx *Tx, q Querier, vals ...Readable) ([]statement, []saveStep, error) {
+	statements := []statement{}
+	owners := []saveStep{}
+
+	for _, inv := range manager.inverses(s) {
+		if inv.holder != s {
+			continue
+		}
+
+		steps, err := inv.push(tx, vals)
+		if err != nil {
+			restoreSteps(owners)
+			return nil, nil, err
+		}
+		owners = append(owners, steps...)
+	}
+
+	// Save the changed owners
+	saved := map[Readable]bool{}
+	for _, step := range owners {
+		if saved[step.object] {
+			continue
+		}
+		saved[step.object] = true
+
+		id, err := step.schema.getID(step.object)
+		if err != nil {
+			restoreSteps(owners)
+			return nil, nil, err
+		}
+
+		strs, err := step.schema.updateSQL(q, id, step.object)
+		if err != nil {
+			restoreSteps(owners)
+			return nil, nil, err
+		}
+		statements = append(statements, strs...)
+	}
+
+	return statements, owners, nil
+}
+
+// newInverse creates an inverse after validating the inverse and relation fields match
+func newInverse(holder *schema, p fieldPlan, owner *schema) (inverse, error) {
+	inv := inverse{holder: holder, index: p.index, owner: owner, links: &links{}}
+
+	// The inverse field must be a pointer or a slice of pointers to the owner
+	ownerType := reflect.PointerTo(reflect.TypeOf(owner.template))
+	if p.field.Type != ownerType && p.field.Type != reflect.SliceOf(ownerType) {
+		return inv, fmt.Errorf("inverse field '%v' must have type %v or []%v", p.field.Name, ownerType, ownerType)
+	}
+
+	// The owner field must be a relation that references the holder
+	rel, ok := owner.namedPlan(p.inverse)
+	if !ok {
+		return inv, fmt.Errorf("inverse field '%v' references missing field '%v' in %v", p.field.Name, p.inverse, owner.name())
+	}
+
+	if rel.through >= 0 {
+		return inv, fmt.Errorf("inverse field '%v' cannot mirror field '%v' with a junction struct", p.field.Name, p.inverse)
+	} else if rel.lazy {
+		return inv, fmt.Errorf("inverse field '%v' cannot mirror lazy field '%v'", p.field.Name, p.inverse)
+	}
+
+	if rel.rel == UndefinedRelationType || rel.skip || rel.target != holder.name() {
+		return inv, fmt.Errorf("inverse field '%v' references field '%v' in %v which is not a relation to %v", p.field.Name, p.inverse, owner.name(), holder.name())
+	}
+
+	// Owners that can reference a holder object more than once need a slice inverse field
+	many := rel.rel == ManyToOne || rel.rel == ManyToMany
+	if many != (p.field.Type.Kind() == reflect.Slice) {
+		return inv, fmt.Errorf("inverse field '%v' does not match the %v relation of field '%v'", p.field.Name, rel.rel, p.inverse)
+	}
+
+	inv.ownerIndex = rel.index
+	inv.ownerIsList = rel.list()
+
+	return inv, nil
+}
+
+// restoreSteps is a helper method that sets the relation fields of owners changed by inverse fields back to their old
+// values, in the reverse order they were changed
+func restoreSteps(steps []saveStep) {
+	for i := len(steps) - 1; i >= 0; i-- {
+		if steps[i].old.IsValid() {
+			reflect.ValueOf(steps[i].object).Elem().Field(steps[i].index).Set(steps[i].old)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package sql_wrapper_test
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+
+	sql_wrapper "github.com/ethanbaker/sql-wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Types ----------
+
+// Author is used to test the owning side of an inverse relation
+type Author struct {
+	Name  string  `sql:"Name" def:"VARCHAR(128)"`
+	Books []*Book `sql:"BookID" rel:"one-to-many"`
+}
+
+// Read reads in Authors from an SQL query
+func (a Author) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM Author")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id   int
+		name string
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &name); err != nil {
+			return items, err
+		}
+
+		obj := Author{Name: name}
+		items[id] = &obj
+	}
+
+	// Query the related elements
+	rows, err = db.Query("SELECT * FROM AuthorBooks")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		authorID int
+		bookID   int
+	)
+	for rows.Next() {
+		if err := rows.Scan(&authorID, &bookID); err != nil {
+			return items, err
+		}
+
+		// Get the referenced object from another schema
+		readable, err := sql_wrapper.GetObjectBySchema("Book", bookID)
+		if err != nil {
+			return items, err
+		}
+		book, ok := readable.(*Book)
+		if !ok {
+			return items, fmt.Errorf("cannot cast object to *Book")
+		}
+
+		// Add the object to the corresponding author
+		author := items[authorID].(*Author)
+		author.Books = append(author.Books, book)
+	}
+
+	return items, nil
+}
+
+// Book is used to test the inverse side of a relation
+type Book struct {
+	Title  string  `sql:"Title" def:"VARCHAR(128)"`
+	Author *Author `inverse:"Books"`
+}
+
+// Read reads in Books from an SQL query
+func (b Book) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM Book")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id    int
+		title string
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &title); err != nil {
+			return items, err
+		}
+
+		obj := Book{Title: title}
+		items[id] = &obj
+	}
+
+	return items, nil
+}
+
+// ---------- Globals ----------
+
+var authorWrapper *sql_wrapper.Wrapper[*Author]
+var bookWrapper *sql_wrapper.Wrapper[*Book]
+
+// ---------- Tests ----------
+
+func TestInverseFromOwner(t *testing.T) {
+	inverseSetup()
+	assert := assert.New(t)
+
+	book1 := Book{Title: "First"}
+	book2 := Book{Title: "Second"}
+	author := Author{Name: "Jack", Books: []*Book{&book1}}
+
+	// Insert the objects
+	_, err := bookWrapper.Insert(&book1)
+	assert.Nil(err)
+
+	_, err = bookWrapper.Insert(&book2)
+	assert.Nil(err)
+
+	_, err = authorWrapper.Insert(&author)
+	assert.Nil(err)
+
+	// The inserted author should be set on its books
+	assert.Equal(&author, book1.Author)
+	assert.Nil(book2.Author)
+
+	// Move the author to the second book
+	author.Books = []*Book{&book2}
+	assert.Nil(authorWrapper.Update(&author))
+
+	assert.Nil(book1.Author)
+	assert.Equal(&author, book2.Author)
+}
+
+func TestInverseFromHolder(t *testing.T) {
+	inverseSetup()
+	assert := assert.New(t)
+
+	book := Book{Title: "First"}
+	author := Author{Name: "John"}
+
+	// Insert the objects
+	bookID, err := bookWrapper.Insert(&book)
+	assert.Nil(err)
+
+	authorID, err := authorWrapper.Insert(&author)
+	assert.Nil(err)
+
+	// Setting the author on the book should add the book to the author
+	book.Author = &author
+	assert.Nil(bookWrapper.Update(&book))
+
+	assert.Equal([]*Book{&book}, author.Books)
+
+	// The link should be saved in the database
+	var (
+		linkedAuthorID int
+		linkedBookID   int
+	)
+	assert.Nil(database.QueryRow("SELECT * FROM AuthorBooks").Scan(&linkedAuthorID, &linkedBookID))
+	assert.Equal(authorID, linkedAuthorID)
+	assert.Equal(bookID, linkedBookID)
+
+	// Removing the author from the book should remove the book from the author
+	book.Author = nil
+	assert.Nil(bookWrapper.Update(&book))
+
+	assert.Equal(0, len(author.Books))
+}
+
+func TestInverseFailedUpdate(t *testing.T) {
+	inverseSetup()
+	assert := assert.New(t)
+
+	book := Book{Title: "First"}
+	author := Author{Name: "Mark"}
+
+	// Insert the objects
+	_, err := bookWrapper.Insert(&book)
+	assert.Nil(err)
+
+	_, err = authorWrapper.Insert(&author)
+	assert.Nil(err)
+
+	// A failed update should not leave the book on the author
+	book.Author = &author
+	book.Title = strings.Repeat("a", 200)
+	assert.NotNil(bookWrapper.Update(&book))
+
+	assert.Equal(0, len(author.Books))
+
+	// The author should still be saved with the book once the update succeeds
+	book.Title = "First"
+	assert.Nil(bookWrapper.Update(&book))
+
+	assert.Equal([]*Book{&book}, author.Books)
+}
+
+func TestInverseOnRead(t *testing.T) {
+	inverseSetup()
+	assert := assert.New(t)
+
+	book := Book{Title: "First"}
+	author := Author{Name: "Luke", Books: []*Book{&book}}
+
+	// Insert the objects
+	bookID, err := bookWrapper.Insert(&book)
+	assert.Nil(err)
+
+	authorID, err := authorWrapper.Insert(&author)
+	assert.Nil(err)
+
+	// Read the objects into new wrappers
+	bookWrapper, err = sql_wrapper.NewWrapper[*Book](database, Book{})
+	assert.Nil(err)
+	assert.Nil(bookWrapper.Read())
+
+	authorWrapper, err = sql_wrapper.NewWrapper[*Author](database, Author{})
+	assert.Nil(err)
+	assert.Nil(authorWrapper.Read())
+
+	// The inverse field should be populated from the owner
+	readBook, err := bookWrapper.GetByID(bookID)
+	assert.Nil(err)
+
+	readAuthor, err := authorWrapper.GetByID(authorID)
+	assert.Nil(err)
+
+	assert.Equal(readAuthor, readBook.Author)
+	assert.Equal("Luke", readBook.Author.Name)
+}
+
+// ---------- Test Setup ----------
+
+func inverseSetup() {
+	// Drop the current wrapper
+	dropTables()
+
+	// Create the new wrappers
+	var err error
+	bookWrapper, err = sql_wrapper.NewWrapper[*Book](database, Book{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	authorWrapper, err = sql_wrapper.NewWrapper[*Author](database, Author{})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
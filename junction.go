@@ -0,0 +1,556 @@
+package sql_wrapper
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultPositionColumn is the name of the position column of an ordered relation without a custom name
+const defaultPositionColumn = "Position"
+
+// getJunctionStruct is a helper method that gets the target field of a slice relation whose elements are junction structs.
+// A slice relation uses a junction struct when the 'through' tag names the struct type of its elements
+func getJunctionStruct(field reflect.StructField) (reflect.StructField, bool) {
+	through, ok := field.Tag.Lookup("through")
+	if !ok || field.Type.Kind() != reflect.Slice || field.Type.Elem().Kind() != reflect.Pointer {
+		return reflect.StructField{}, false
+	}
+
+	t := field.Type.Elem().Elem()
+	if t.Kind() != reflect.Struct || t.Name() != through {
+		return reflect.StructField{}, false
+	}
+
+	// The target is the pointer field with a relation in the junction struct
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type.Kind() == reflect.Pointer && getRelation(t.Field(i)) != UndefinedRelationType {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// getJunctionColumns is a helper method that gets the extra columns stored in a junction struct, mapped to their field index
+func getJunctionColumns(field reflect.StructField) (map[int]string, error) {
+	columns := map[int]string{}
+
+	target, ok := getJunctionStruct(field)
+	if !ok {
+		return columns, nil
+	}
+
+	t := field.Type.Elem().Elem()
+	for i := 0; i < t.NumField(); i++ {
+		if i == target.Index[0] {
+			continue
+		}
+
+		name, err := getName(t.Field(i))
+		if err != nil {
+			return columns, err
+		} else if name == "-" {
+			continue
+		}
+
+		columns[i] = name
+	}
+
+	return columns, nil
+}
+
+// getLinkColumn is a helper method that gets the name of the target column in the table of a slice relation field.
+// The column is named by the junction struct's target field when present
+func getLinkColumn(field reflect.StructField) (string, error) {
+	if target, ok := getJunctionStruct(field); ok {
+		return getName(target)
+	}
+	return getName(field)
+}
+
+// getPositionColumn is a helper method that gets the position column of an ordered slice relation field
+func getPositionColumn(field reflect.StructField) (string, bool) {
+	val, ok := field.Tag.Lookup("ordered")
+	if !ok {
+		return "", false
+	} else if val == "" {
+		return defaultPositionColumn, true
+	}
+	return val, true
+}
+
+// getLinkTarget is a helper method that gets the target object of an element in a slice relation
+func getLinkTarget(field reflect.StructField, elem reflect.Value) (Readable, error) {
+	if elem.IsNil() {
+		return nil, fmt.Errorf("cannot link nil element in relationship")
+	}
+
+	// The target of a junction struct is stored in its target field
+	if target, ok := getJunctionStruct(field); ok {
+		elem = elem.Elem().Field(target.Index[0])
+		if elem.IsNil() {
+			return nil, fmt.Errorf("junction struct in relationship does not have a target")
+		}
+	}
+
+	readable, ok := elem.Interface().(Readable)
+	if !ok {
+		return nil, fmt.Errorf("cannot cast element in relationship to Readable")
+	}
+	return readable, nil
+}
+
+// createJunctionSQL creates a string that will create the table storing the links of a slice relation field
+func (s *schema) createJunctionSQL(p fieldPlan) (string, error) {
+	// The column of the target cannot share the name of the column of the source
+	if p.name == s.table+"ID" {
+		return "", fmt.Errorf("field '%v' cannot use the name '%v' since it is used for the source in table '%v'", p.field.Name, p.name, p.junction)
+	}
+
+	// Targets of a one-to-many relation can only be linked once
+	unique := ""
+	if p.rel == OneToMany {
+		unique = " UNIQUE"
+	}
+
+	// Add the extra columns of a junction struct and the position column of an ordered relation
+	extra := ""
+	for k, col := range p.extras {
+		if p.extraIndexes[k] < 0 {
+			extra += fmt.Sprintf("%v INT UNSIGNED NOT NULL, ", col)
+		} else {
+			extra += fmt.Sprintf("%v %v, ", col, p.extraDefs[k])
+		}
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %[1]v(%[2]vID INT UNSIGNED, %[4]v INT UNSIGNED%[5]v, %[6]vFOREIGN KEY (%[2]vID) REFERENCES %[2]v(id) ON DELETE CASCADE, FOREIGN KEY (%[4]v) REFERENCES %[3]v(id) ON DELETE %[7]v ON UPDATE %[8]v);", p.junction, s.table, p.target, p.name, unique, extra, p.onDelete, p.onUpdate), nil
+}
+
+// legacyJunction is a helper method that gets the name the table of a slice relation field had when tables were named
+// by the target schema instead of the field. Fields with a 'through' tag always used the name in the tag
+func (s *schema) legacyJunction(p fieldPlan) (string, bool) {
+	if _, ok := p.field.Tag.Lookup("through"); ok || p.lazy {
+		return "", false
+	}
+
+	legacy := s.table + p.target
+	return legacy, legacy != p.junction
+}
+
+// renameJunctionsSQL creates strings that will rename tables of slice relation fields that still have their legacy
+// name. A legacy table shared by more than one field is left alone, since its links cannot be split between them
+func (s *schema) renameJunctionsSQL(tx *sql.Tx) ([]string, error) {
+	statements := []string{}
+
+	// Count the fields that used each legacy name
+	uses := map[string]int{}
+	for _, p := range s.plan {
+		if legacy, ok := s.legacyJunction(p); ok && !p.skip && p.list() {
+			uses[legacy]++
+		}
+	}
+
+	for _, p := range s.plan {
+		legacy, ok := s.legacyJunction(p)
+		if !ok || p.skip || !p.list() || uses[legacy] != 1 {
+			continue
+		}
+
+		// Only rename the legacy table if the table of the field does not exist yet
+		found, err := tableExists(tx, legacy)
+		if err != nil {
+			return statements, err
+		} else if !found {
+			continue
+		}
+
+		found, err = tableExists(tx, p.junction)
+		if err != nil {
+			return statements, err
+		} else if !found {
+			statements = append(statements, fmt.Sprintf("RENAME TABLE %v TO %v;", legacy, p.junction))
+		}
+	}
+
+	return statements, nil
+}
+
+// tableExists is a helper method that checks if a table exists in the current database
+func tableExists(tx *sql.Tx, table string) (bool, error) {
+	var count int
+	err := tx.QueryRow("SELECT COUNT(*) FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?;", table).Scan(&count)
+	return count > 0, err
+}
+
+// link is a row in the table of a slice relation field
+type link struct {
+	target int           // The ID of the target object
+	extras []interface{} // The values of the extra columns, with the types of the fields they are stored from
+}
+
+// placeholders is a helper method that creates the row of a link for a multi-row insert, binding the values of the
+// extra columns to placeholders
+func (row link) placeholders(id int) statement {
+	values := []string{fmt.Sprint(row.target), fmt.Sprint(id)}
+	for range row.extras {
+		values = append(values, "?")
+	}
+
+	return statement{query: fmt.Sprintf("(%v)", strings.Join(values, ", ")), args: row.extras}
+}
+
+// sameValue is a helper method that checks if two values of an extra column are equal. Times are compared by
+// instant, since the database does not keep their location
+func sameValue(a interface{}, b interface{}) bool {
+	if t, ok := a.(time.Time); ok {
+		u, ok := b.(time.Time)
+		return ok && t.Equal(u)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// getLinkExtras is a helper method that gets the columns stored in the table of a slice relation field after the
+// target and source columns, together with the index of the junction struct field they are read from (-1 for the position)
+func getLinkExtras(field reflect.StructField) ([]string, []int, error) {
+	names := []string{}
+	indexes := []int{}
+
+	columns, err := getJunctionColumns(field)
+	if err != nil {
+		return names, indexes, err
+	}
+
+	if _, ok := getJunctionStruct(field); ok {
+		for i := 0; i < field.Type.Elem().Elem().NumField(); i++ {
+			if col, ok := columns[i]; ok {
+				names = append(names, col)
+				indexes = append(indexes, i)
+			}
+		}
+	}
+
+	if position, ok := getPositionColumn(field); ok {
+		names = append(names, position)
+		indexes = append(indexes, -1)
+	}
+
+	return names, indexes, nil
+}
+
+// links creates the rows that store the elements of a slice relation field, starting at the given element
+func (s *schema) links(field reflect.StructField, slice reflect.Value, start int) ([]link, error) {
+	rows := []link{}
+	p := s.fieldPlan(field)
+
+	// Lazy relations store the IDs of their targets
+	if p.lazy {
+		ids, err := lazyIDs(p.target, slice)
+		if err != nil {
+			return rows, err
+		}
+
+		for i := start; i < len(ids); i++ {
+			row := link{target: ids[i]}
+			if p.ordered {
+				row.extras = []interface{}{i}
+			}
+			rows = append(rows, row)
+		}
+
+		return rows, nil
+	}
+
+	if slice.Kind() != reflect.Slice {
+		return rows, fmt.Errorf("relationship does not have slice type")
+	}
+
+	// Get the schema
+	schema, err := manager.getSchema(p.target)
+	if err != nil {
+		return rows, err
+	}
+
+	for i := start; i < slice.Len(); i++ {
+		val := slice.Index(i)
+
+		// Get the ID of the target object
+		readable, err := getLinkTarget(field, val)
+		if err != nil {
+			return rows, err
+		}
+
+		objID, err := schema.getID(readable)
+		if err != nil {
+			return rows, err
+		}
+
+		// Add the values of the extra columns and the position
+		row := link{target: objID}
+		for _, k := range p.extraIndexes {
+			if k < 0 {
+				row.extras = append(row.extras, i)
+				continue
+			}
+
+			row.extras = append(row.extras, val.Elem().Field(k).Interface())
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// insertLinksSQL creates a statement that will insert rows into the table of a slice relation field for the object with
+// the given ID. The values of the extra columns are bound as arguments
+func (s *schema) insertLinksSQL(id int, field reflect.StructField, rows []link) (statement, error) {
+	p := s.fieldPlan(field)
+
+	values := []string{}
+	args := []interface{}{}
+	for _, row := range rows {
+		placeholders := row.placeholders(id)
+		values = append(values, placeholders.query)
+		args = append(args, placeholders.args...)
+	}
+
+	columns := append([]string{p.name, s.table + "ID"}, p.extras...)
+	return statement{query: fmt.Sprintf("INSERT INTO %v(%v) VALUES %v;", p.junction, strings.Join(columns, ", "), strings.Join(values, ", ")), args: args}, nil
+}
+
+// linksSQL creates statements that will insert the links of a slice relation field for the object with the given ID
+func (s *schema) linksSQL(id int, field reflect.StructField, slice reflect.Value) ([]statement, error) {
+	statements := []statement{}
+
+	rows, err := s.links(field, slice, 0)
+	if err != nil || len(rows) == 0 {
+		return statements, err
+	}
+
+	str, err := s.insertLinksSQL(id, field, rows)
+	if err != nil {
+		return statements, err
+	}
+
+	return append(statements, str), nil
+}
+
+// readLinks reads the rows currently stored in the table of a slice relation field for the object with the given ID.
+// The extra columns are read into the types of the fields they are stored from, and the lock clause is added to the
+// select statement if it is not empty
+func (s *schema) readLinks(q Querier, id int, field reflect.StructField, lock string) ([]link, error) {
+	rows := []link{}
+	p := s.fieldPlan(field)
+
+	query := fmt.Sprintf("SELECT %v FROM %v WHERE %vID = %v", strings.Join(append([]string{p.name}, p.extras...), ", "), p.junction, s.table, id)
+	if lock != "" {
+		query += " " + lock
+	}
+
+	result, err := q.Query(query + ";")
+	if err != nil {
+		return rows, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		row := link{}
+
+		// The position is an int, and the other extra columns are read into the types of their fields
+		dest := []interface{}{&row.target}
+		for _, k := range p.extraIndexes {
+			if k < 0 {
+				dest = append(dest, new(int))
+			} else {
+				dest = append(dest, reflect.New(field.Type.Elem().Elem().Field(k).Type).Interface())
+			}
+		}
+
+		if err := result.Scan(dest...); err != nil {
+			return rows, err
+		}
+
+		for _, value := range dest[1:] {
+			row.extras = append(row.extras, reflect.ValueOf(value).Elem().Interface())
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, result.Err()
+}
+
+// updateLinksSQL creates strings that will change the links of a slice relation field for the object with the given ID
+// to match the slice. Only links that were added, removed or changed are written
+func (s *schema) updateLinksSQL(q Querier, id int, field reflect.StructField, slice reflect.Value) ([]statement, error) {
+	statements := []statement{}
+	p := s.fieldPlan(field)
+	combinedTable, name, extras := p.junction, p.name, p.extras
+
+	// Get the wanted and existing links
+	wanted, err := s.links(field, slice, 0)
+	if err != nil {
+		return statements, err
+	}
+
+	// Lock the links so they cannot change before the statements run
+	existing, err := s.readLinks(q, id, field, "FOR UPDATE")
+	if err != nil {
+		return statements, err
+	}
+
+	wantedByTarget := map[int]link{}
+	for _, row := range wanted {
+		wantedByTarget[row.target] = row
+	}
+
+	existingByTarget := map[int]link{}
+	for _, row := range existing {
+		existingByTarget[row.target] = row
+	}
+
+	// Links cannot be matched by target when a target is linked more than once, so every link is rewritten
+	if len(wantedByTarget) != len(wanted) || len(existingByTarget) != len(existing) {
+		statements = append(statements, statement{query: fmt.Sprintf("DELETE FROM %v WHERE %vID = %v;", combinedTable, s.table, id)})
+
+		strs, err := s.linksSQL(id, field, slice)
+		return append(statements, strs...), err
+	}
+
+	// Remove links to targets that are no longer present
+	removed := []string{}
+	for _, row := range existing {
+		if _, ok := wantedByTarget[row.target]; !ok {
+			removed = append(removed, fmt.Sprint(row.target))
+		}
+	}
+	if len(removed) > 0 {
+		statements = append(statements, statement{query: fmt.Sprintf("DELETE FROM %v WHERE %vID = %v AND %v IN (%v);", combinedTable, s.table, id, name, strings.Join(removed, ", "))})
+	}
+
+	// Change the extra columns of links that are present in both
+	added := []link{}
+	for _, row := range wanted {
+		old, ok := existingByTarget[row.target]
+		if !ok {
+			added = append(added, row)
+			continue
+		}
+
+		changes := []string{}
+		args := []interface{}{}
+		for k := range extras {
+			if !sameValue(old.extras[k], row.extras[k]) {
+				changes = append(changes, extras[k]+" = ?")
+				args = append(args, row.extras[k])
+			}
+		}
+		if len(changes) > 0 {
+			statements = append(statements, statement{query: fmt.Sprintf("UPDATE %v SET %v WHERE %vID = %v AND %v = %v;", combinedTable, strings.Join(changes, ", "), s.table, id, name, row.target), args: args})
+		}
+	}
+
+	// Add links to new targets
+	if len(added) > 0 {
+		str, err := s.insertLinksSQL(id, field, added)
+		if err != nil {
+			return statements, err
+		}
+		statements = append(statements, str)
+	}
+
+	return statements, nil
+}
+
+// orderLinks sorts the elements of ordered slice relations by the positions stored in their tables
+func (s *schema) orderLinks(tx *Tx) error {
+	for _, p := range s.plan {
+		// Lazy relations are read in order of position
+		if !p.ordered || p.lazy || p.skip {
+			continue
+		}
+
+		schema, err := manager.getSchema(p.target)
+		if err != nil {
+			return err
+		}
+
+		// Read the position of each link
+		positions := map[[2]int]int{}
+
+		rows, err := s.conn(tx).Query(fmt.Sprintf("SELECT %vID, %v, %v FROM %v;", s.table, p.name, p.position, p.junction))
+		if err != nil {
+			return err
+		}
+
+		var (
+			ownerID  int
+			targetID int
+			pos      int
+		)
+		for rows.Next() {
+			if err := rows.Scan(&ownerID, &targetID, &pos); err != nil {
+				rows.Close()
+				return err
+			}
+
+			if _, ok := positions[[2]int{ownerID, targetID}]; !ok {
+				positions[[2]int{ownerID, targetID}] = pos
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		// Sort the elements of each object by position
+		for id, obj := range s.objects {
+			tx.recordField(obj.Object(), p.index)
+			slice := reflect.ValueOf(obj.Object()).Elem().Field(p.index)
+
+			keys := make([]int, slice.Len())
+			for k := 0; k < slice.Len(); k++ {
+				keys[k] = slice.Len()
+
+				target, err := getLinkTarget(p.field, slice.Index(k))
+				if err != nil {
+					continue
+				}
+				if targetID, err := schema.getID(target); err == nil {
+					if pos, ok := positions[[2]int{id, targetID}]; ok {
+						keys[k] = pos
+					}
+				}
+			}
+
+			// Sort the keys together with the elements
+			sort.Stable(linkSorter{slice: slice, keys: keys})
+		}
+	}
+
+	return nil
+}
+
+// linkSorter sorts the elements of a slice relation by their positions
+type linkSorter struct {
+	slice reflect.Value
+	keys  []int
+}
+
+func (l linkSorter) Len() int {
+	return len(l.keys)
+}
+
+func (l linkSorter) Less(i, j int) bool {
+	return l.keys[i] < l.keys[j]
+}
+
+func (l linkSorter) Swap(i, j int) {
+	l.keys[i], l.keys[j] = l.keys[j], l.keys[i]
+
+	temp := reflect.ValueOf(l.slice.Index(i).Interface())
+	l.slice.Index(i).Set(l.slice.Index(j))
+	l.slice.Index(j).Set(temp)
+}
@@ -0,0 +1,485 @@
+package sql_wrapper_test
+
+import (
+	"database/sql"
+	"log"
+	"testing"
+	"time"
+
+	sql_wrapper "github.com/ethanbaker/sql-wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Types ----------
+
+// Club is used as the target of a junction struct
+type Club struct {
+	Name string `sql:"Name" def:"VARCHAR(128)"`
+}
+
+// Read reads in Clubs from an SQL query
+func (c Club) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM Club")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id   int
+		name string
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &name); err != nil {
+			return items, err
+		}
+
+		obj := Club{Name: name}
+		items[id] = &obj
+	}
+
+	return items, nil
+}
+
+// Membership is used as a junction struct with extra attributes
+type Membership struct {
+	Club   *Club     `sql:"ClubID" rel:"many-to-one"`
+	Role   string    `sql:"Role" def:"VARCHAR(32)"`
+	Joined time.Time `sql:"Joined" def:"DATE"`
+}
+
+// Person is used to test junction structs
+type Person struct {
+	Name        string        `sql:"Name" def:"VARCHAR(128)"`
+	Memberships []*Membership `rel:"many-to-many" through:"Membership"`
+}
+
+// Read reads in People from an SQL query
+func (p Person) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM Person")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id   int
+		name string
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &name); err != nil {
+			return items, err
+		}
+
+		obj := Person{Name: name}
+		items[id] = &obj
+	}
+
+	// Query the junction structs
+	rows, err = db.Query("SELECT PersonID, ClubID, Role, Joined FROM Membership")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		personID int
+		clubID   int
+		role     string
+		joined   time.Time
+	)
+	for rows.Next() {
+		if err := rows.Scan(&personID, &clubID, &role, &joined); err != nil {
+			return items, err
+		}
+
+		// Get the referenced club from another schema
+		club, err := sql_wrapper.GetObject[*Club](clubID)
+		if err != nil {
+			return items, err
+		}
+
+		// Add the junction struct to the corresponding person
+		person := items[personID].(*Person)
+		person.Memberships = append(person.Memberships, &Membership{Club: club, Role: role, Joined: joined})
+	}
+
+	return items, nil
+}
+
+// Song is used as the target of an ordered relation
+type Song struct {
+	Title string `sql:"Title" def:"VARCHAR(128)"`
+}
+
+// Read reads in Songs from an SQL query
+func (s Song) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM Song")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id    int
+		title string
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &title); err != nil {
+			return items, err
+		}
+
+		obj := Song{Title: title}
+		items[id] = &obj
+	}
+
+	return items, nil
+}
+
+// Playlist is used to test ordered relations
+type Playlist struct {
+	Name  string  `sql:"Name" def:"VARCHAR(128)"`
+	Songs []*Song `sql:"SongID" rel:"many-to-many" ordered:""`
+}
+
+// Read reads in Playlists from an SQL query
+func (p Playlist) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM Playlist")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id   int
+		name string
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &name); err != nil {
+			return items, err
+		}
+
+		obj := Playlist{Name: name}
+		items[id] = &obj
+	}
+
+	// Query the related elements in an order that differs from their positions
+	rows, err = db.Query("SELECT PlaylistID, SongID FROM PlaylistSongs ORDER BY SongID")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		playlistID int
+		songID     int
+	)
+	for rows.Next() {
+		if err := rows.Scan(&playlistID, &songID); err != nil {
+			return items, err
+		}
+
+		// Get the referenced song from another schema
+		readable, err := sql_wrapper.GetObjectBySchema("Song", songID)
+		if err != nil {
+			return items, err
+		}
+
+		playlist := items[playlistID].(*Playlist)
+		playlist.Songs = append(playlist.Songs, readable.(*Song))
+	}
+
+	return items, nil
+}
+
+// ---------- Globals ----------
+
+var clubWrapper *sql_wrapper.Wrapper[*Club]
+var personWrapper *sql_wrapper.Wrapper[*Person]
+var songWrapper *sql_wrapper.Wrapper[*Song]
+var playlistWrapper *sql_wrapper.Wrapper[*Playlist]
+
+// ---------- Tests ----------
+
+// date is a helper method that creates the time of a DATE column as the driver reads it
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestJunctionStruct(t *testing.T) {
+	junctionSetup()
+	assert := assert.New(t)
+
+	chess := Club{Name: "Chess"}
+	hiking := Club{Name: "Hiking"}
+	person := Person{Name: "Jack", Memberships: []*Membership{
+		{Club: &chess, Role: "Captain", Joined: date(2023, time.January, 15)},
+		{Club: &hiking, Role: "Member", Joined: date(2024, time.March, 1)},
+	}}
+
+	// Insert the objects
+	chessID, err := clubWrapper.Insert(&chess)
+	assert.Nil(err)
+
+	_, err = clubWrapper.Insert(&hiking)
+	assert.Nil(err)
+
+	personID, err := personWrapper.Insert(&person)
+	assert.Nil(err)
+
+	// The extra attributes should be stored in the junction table
+	var (
+		role   string
+		joined time.Time
+	)
+	assert.Nil(database.QueryRow("SELECT Role, Joined FROM Membership WHERE PersonID = ? AND ClubID = ?", personID, chessID).Scan(&role, &joined))
+	assert.Equal("Captain", role)
+	assert.Equal(date(2023, time.January, 15), joined)
+
+	// Change an attribute and remove a membership
+	person.Memberships[0].Role = "Member"
+	person.Memberships = person.Memberships[:1]
+	assert.Nil(personWrapper.Update(&person))
+
+	var count int
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM Membership").Scan(&count))
+	assert.Equal(1, count)
+
+	// Read the objects into new wrappers
+	clubWrapper, err = sql_wrapper.NewWrapper[*Club](database, Club{})
+	assert.Nil(err)
+	assert.Nil(clubWrapper.Read())
+
+	personWrapper, err = sql_wrapper.NewWrapper[*Person](database, Person{})
+	assert.Nil(err)
+	assert.Nil(personWrapper.Read())
+
+	readPerson, err := personWrapper.GetByID(personID)
+	assert.Nil(err)
+	assert.Equal(1, len(readPerson.Memberships))
+	assert.Equal("Chess", readPerson.Memberships[0].Club.Name)
+	assert.Equal("Member", readPerson.Memberships[0].Role)
+	assert.Equal(date(2023, time.January, 15), readPerson.Memberships[0].Joined)
+}
+
+func TestReloadJunctionStruct(t *testing.T) {
+	junctionSetup()
+	assert := assert.New(t)
+
+	chess := Club{Name: "Chess"}
+	person := Person{Name: "Jack", Memberships: []*Membership{{Club: &chess, Role: "Member", Joined: date(2023, time.January, 15)}}}
+
+	// Insert the objects
+	_, err := clubWrapper.Insert(&chess)
+	assert.Nil(err)
+
+	personID, err := personWrapper.Insert(&person)
+	assert.Nil(err)
+
+	// Change the link behind the wrapper's back
+	_, err = database.Exec("UPDATE Membership SET Role = 'Captain', Joined = '2024-03-01' WHERE PersonID = ?", personID)
+	assert.Nil(err)
+
+	// Reloading builds the junction structs from the links
+	assert.Nil(personWrapper.Reload(&person))
+	assert.Equal(1, len(person.Memberships))
+	assert.True(person.Memberships[0].Club == &chess)
+	assert.Equal("Captain", person.Memberships[0].Role)
+	assert.Equal(date(2024, time.March, 1), person.Memberships[0].Joined)
+
+	changes, err := personWrapper.Changes(&person)
+	assert.Nil(err)
+	assert.Empty(changes)
+}
+
+func TestDeleteJunctionStructTarget(t *testing.T) {
+	junctionSetup()
+	assert := assert.New(t)
+
+	chess := Club{Name: "Chess"}
+	person := Person{Name: "John", Memberships: []*Membership{{Club: &chess, Role: "Member", Joined: date(2024, time.March, 1)}}}
+
+	// Insert the objects
+	_, err := clubWrapper.Insert(&chess)
+	assert.Nil(err)
+
+	_, err = personWrapper.Insert(&person)
+	assert.Nil(err)
+
+	// Deleting a club that is linked without a cascade should fail
+	assert.NotNil(clubWrapper.Delete(&chess))
+	assert.Equal(1, len(person.Memberships))
+}
+
+func TestOrderedRelation(t *testing.T) {
+	junctionSetup()
+	assert := assert.New(t)
+
+	first := Song{Title: "First"}
+	second := Song{Title: "Second"}
+	third := Song{Title: "Third"}
+	playlist := Playlist{Name: "Mix", Songs: []*Song{&third, &first, &second}}
+
+	// Insert the objects
+	for _, song := range []*Song{&first, &second, &third} {
+		_, err := songWrapper.Insert(song)
+		assert.Nil(err)
+	}
+
+	playlistID, err := playlistWrapper.Insert(&playlist)
+	assert.Nil(err)
+
+	// Reorder the songs
+	playlist.Songs = []*Song{&second, &third, &first}
+	assert.Nil(playlistWrapper.Update(&playlist))
+
+	// Read the objects into new wrappers
+	songWrapper, err = sql_wrapper.NewWrapper[*Song](database, Song{})
+	assert.Nil(err)
+	assert.Nil(songWrapper.Read())
+
+	playlistWrapper, err = sql_wrapper.NewWrapper[*Playlist](database, Playlist{})
+	assert.Nil(err)
+	assert.Nil(playlistWrapper.Read())
+
+	// The songs should be in the saved order
+	readPlaylist, err := playlistWrapper.GetByID(playlistID)
+	assert.Nil(err)
+	assert.Equal(3, len(readPlaylist.Songs))
+	assert.Equal("Second", readPlaylist.Songs[0].Title)
+	assert.Equal("Third", readPlaylist.Songs[1].Title)
+	assert.Equal("First", readPlaylist.Songs[2].Title)
+}
+
+func TestAddRemoveRelation(t *testing.T) {
+	junctionSetup()
+	assert := assert.New(t)
+
+	first := Song{Title: "First"}
+	second := Song{Title: "Second"}
+	playlist := Playlist{Name: "Mix", Songs: []*Song{&first}}
+
+	// Insert the objects
+	_, err := songWrapper.Insert(&first)
+	assert.Nil(err)
+
+	secondID, err := songWrapper.Insert(&second)
+	assert.Nil(err)
+
+	playlistID, err := playlistWrapper.Insert(&playlist)
+	assert.Nil(err)
+
+	// Adding a song should only insert its link at the end
+	assert.Nil(playlistWrapper.AddRelation(&playlist, "Songs", &second))
+	assert.Equal([]*Song{&first, &second}, playlist.Songs)
+
+	var position int
+	assert.Nil(database.QueryRow("SELECT Position FROM PlaylistSongs WHERE PlaylistID = ? AND SongID = ?", playlistID, secondID).Scan(&position))
+	assert.Equal(1, position)
+
+	// Removing a song should only delete its link
+	assert.Nil(playlistWrapper.RemoveRelation(&playlist, "Songs", &first))
+	assert.Equal([]*Song{&second}, playlist.Songs)
+
+	var count int
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM PlaylistSongs").Scan(&count))
+	assert.Equal(1, count)
+
+	// Invalid changes should not modify the object
+	assert.NotNil(playlistWrapper.RemoveRelation(&playlist, "Songs", &first))
+	assert.NotNil(playlistWrapper.AddRelation(&playlist, "Name", &first))
+	assert.NotNil(playlistWrapper.AddRelation(&playlist, "Missing", &first))
+	assert.NotNil(playlistWrapper.AddRelation(&playlist, "Songs", &Song{Title: "Unsaved"}))
+	assert.Equal([]*Song{&second}, playlist.Songs)
+}
+
+func TestSetRelation(t *testing.T) {
+	junctionSetup()
+	assert := assert.New(t)
+
+	chess := Club{Name: "Chess"}
+	hiking := Club{Name: "Hiking"}
+	membership := Membership{Club: &chess, Role: "Member", Joined: date(2023, time.January, 15)}
+	person := Person{Name: "Jack", Memberships: []*Membership{&membership}}
+
+	// Insert the objects
+	chessID, err := clubWrapper.Insert(&chess)
+	assert.Nil(err)
+
+	_, err = clubWrapper.Insert(&hiking)
+	assert.Nil(err)
+
+	personID, err := personWrapper.Insert(&person)
+	assert.Nil(err)
+
+	// Setting the memberships should keep the existing link and add the new one
+	membership.Role = "Captain"
+	assert.Nil(personWrapper.SetRelation(&person, "Memberships", &membership, &Membership{Club: &hiking, Role: "Member", Joined: date(2024, time.March, 1)}))
+	assert.Equal(2, len(person.Memberships))
+
+	var role string
+	assert.Nil(database.QueryRow("SELECT Role FROM Membership WHERE PersonID = ? AND ClubID = ?", personID, chessID).Scan(&role))
+	assert.Equal("Captain", role)
+
+	// Removing by the target of the junction struct should delete the link
+	assert.Nil(personWrapper.RemoveRelation(&person, "Memberships", &hiking))
+	assert.Equal([]*Membership{&membership}, person.Memberships)
+
+	var count int
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM Membership").Scan(&count))
+	assert.Equal(1, count)
+
+	// Setting no values should remove every link
+	assert.Nil(personWrapper.SetRelation(&person, "Memberships"))
+	assert.Equal(0, len(person.Memberships))
+
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM Membership").Scan(&count))
+	assert.Equal(0, count)
+}
+
+// ---------- Test Setup ----------
+
+func junctionSetup() {
+	// Drop the current wrapper
+	dropTables()
+
+	// Create the new wrappers
+	var err error
+	clubWrapper, err = sql_wrapper.NewWrapper[*Club](database, Club{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	personWrapper, err = sql_wrapper.NewWrapper[*Person](database, Person{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	songWrapper, err = sql_wrapper.NewWrapper[*Song](database, Song{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	playlistWrapper, err = sql_wrapper.NewWrapper[*Playlist](database, Playlist{})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,70 @@
+package sql_wrapper
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// LockOption sets what a row lock does when the row is already locked by another transaction
+type LockOption int
+
+const (
+	LockWait   LockOption = iota // Wait until the row is released
+	NoWait                       // Fail immediately
+	SkipLocked                   // Skip the row, which is reported as not found
+)
+
+// String converts the lock option into the clause added to the lock
+func (o LockOption) String() string {
+	switch o {
+	case NoWait:
+		return "NOWAIT"
+	case SkipLocked:
+		return "SKIP LOCKED"
+	default:
+		return ""
+	}
+}
+
+// getLockOption is a helper method that gets the lock option from the optional options of a lock
+func getLockOption(options []LockOption) (LockOption, error) {
+	if len(options) == 0 {
+		return LockWait, nil
+	} else if len(options) > 1 {
+		return LockWait, fmt.Errorf("cannot use more than one lock option")
+	}
+
+	switch options[0] {
+	case LockWait, NoWait, SkipLocked:
+		return options[0], nil
+	}
+	return LockWait, fmt.Errorf("invalid lock option %v", int(options[0]))
+}
+
+// lock locks the row with the given ID in a transaction and reads the locked values into its object. The lock is
+// released when the transaction is committed or rolled back
+func (s *schema) lock(tx *Tx, id int, mode string, options []LockOption) (Readable, error) {
+	if tx == nil {
+		return nil, fmt.Errorf("rows can only be locked in a transaction")
+	}
+
+	option, err := getLockOption(options)
+	if err != nil {
+		return nil, err
+	}
+
+	clause := mode
+	if option != LockWait {
+		clause += " " + option.String()
+	}
+
+	obj, err := s.readRow(tx, id, clause)
+	if err == sql.ErrNoRows {
+		// Skipped rows may still exist
+		if option != SkipLocked {
+			s.evict(tx, id)
+		}
+		return nil, &ObjectNotFoundError{Schema: s.name(), ID: id}
+	}
+	return obj, err
+}
@@ -0,0 +1,87 @@
+package sql_wrapper_test
+
+import (
+	"testing"
+
+	sql_wrapper "github.com/ethanbaker/sql-wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Tests ----------
+
+func TestLockForUpdate(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	obj := TestObject{Name: "Jack", Age: 20, Weather: Summer}
+	id, err := wrapper.Insert(&obj)
+	assert.Nil(err)
+
+	// Rows can only be locked in a transaction
+	_, err = wrapper.LockForUpdate(id)
+	assert.NotNil(err)
+
+	// Change the row behind the wrapper's back
+	_, err = database.Exec("UPDATE TestObject SET Age = 30 WHERE id = ?", id)
+	assert.Nil(err)
+
+	tx, err := sql_wrapper.Begin(database)
+	assert.Nil(err)
+	txWrapper := wrapper.WithTx(tx)
+
+	// Locking refreshes the loaded object with the locked values
+	locked, err := txWrapper.LockForUpdate(id)
+	assert.Nil(err)
+	assert.True(locked == &obj)
+	assert.Equal(30, obj.Age)
+
+	// Other transactions cannot lock the row
+	other, err := database.Begin()
+	assert.Nil(err)
+	_, err = other.Exec("SELECT id FROM TestObject WHERE id = ? FOR UPDATE NOWAIT", id)
+	assert.NotNil(err)
+	assert.Nil(other.Rollback())
+
+	// Writes in the transaction keep the lock
+	obj.Age--
+	assert.Nil(txWrapper.Update(&obj))
+	assert.Nil(tx.Commit())
+
+	var age int
+	assert.Nil(database.QueryRow("SELECT Age FROM TestObject WHERE id = ?", id).Scan(&age))
+	assert.Equal(29, age)
+}
+
+func TestLockOptions(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	obj := TestObject{Name: "Jack", Age: 20, Weather: Summer}
+	id, err := wrapper.Insert(&obj)
+	assert.Nil(err)
+
+	// Lock the row in another transaction
+	other, err := database.Begin()
+	assert.Nil(err)
+	_, err = other.Exec("SELECT id FROM TestObject WHERE id = ? FOR UPDATE", id)
+	assert.Nil(err)
+
+	tx, err := sql_wrapper.Begin(database)
+	assert.Nil(err)
+	txWrapper := wrapper.WithTx(tx)
+
+	// Locked rows are skipped or fail immediately
+	var notFound *sql_wrapper.ObjectNotFoundError
+	_, err = txWrapper.LockForUpdate(id, sql_wrapper.SkipLocked)
+	assert.ErrorAs(err, &notFound)
+
+	_, err = txWrapper.LockShared(id, sql_wrapper.NoWait)
+	assert.NotNil(err)
+
+	// Skipped objects stay in the wrapper
+	_, err = wrapper.GetID(&obj)
+	assert.Nil(err)
+
+	assert.Nil(tx.Rollback())
+	assert.Nil(other.Rollback())
+}
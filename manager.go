@@ -1,10 +1,36 @@
 package sql_wrapper
 
-import "fmt"
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
 
 // schemaManager manages multiple schemas together and handles foreign references
 type schemaManager struct {
-	schemas map[string]*schema
+	schemas      map[string]*schema
+	deferred     map[string]bool          // Schemas whose objects resolve to placeholders while a cycle is read
+	placeholders map[placeholder]Readable // The placeholders handed out while a cycle is read
+	invs         []inverse                // The inverse fields whose owners are registered
+}
+
+// placeholder is an object in a schema that is not read yet, which is replaced once the schema is read
+type placeholder struct {
+	schema *schema // The schema the object is read into
+	id     int     // The ID of the object
+}
+
+// placeholder gets the placeholder of an object in a deferred schema, creating an empty object the first time the
+// object is asked for
+func (m *schemaManager) placeholder(s *schema, id int) Readable {
+	key := placeholder{schema: s, id: id}
+	if obj, ok := m.placeholders[key]; ok {
+		return obj
+	}
+
+	obj := reflect.New(reflect.TypeOf(s.template)).Interface().(Readable)
+	m.placeholders[key] = obj
+	return obj
 }
 
 // addSchema adds a schema to the schemaManager
@@ -22,23 +48,144 @@ func (m *schemaManager) addSchema(s interface{}) error {
 
 	m.schemas[readableSchema.name()] = readableSchema
 
-	return nil
+	// Make sure inverse fields match the relations they mirror
+	return m.linkInverses()
 }
 
 // getSchema returns a schema with a given name
 func (m *schemaManager) getSchema(name string) (*schema, error) {
 	schema, ok := m.schemas[name]
 	if !ok {
-		return nil, fmt.Errorf("schema is not in schemaManager")
+		return nil, &UnregisteredSchemaError{Name: name}
 	}
 
 	return schema, nil
 }
 
+// reference is a relation field in one schema that references another schema
+type reference struct {
+	fieldPlan         // The plan of the relation field
+	source    *schema // The schema that holds the relation field
+}
+
+// referrers returns the relation fields in every schema that reference the given schema
+func (m *schemaManager) referrers(target *schema) []reference {
+	refs := []reference{}
+
+	for _, s := range m.schemas {
+		for _, p := range s.plan {
+			if !p.skip && p.rel != UndefinedRelationType && p.target == target.name() {
+				refs = append(refs, reference{fieldPlan: p, source: s})
+			}
+		}
+	}
+
+	return refs
+}
+
+// removal is an object removed by a cascade
+type removal struct {
+	source *schema // The schema the object was removed from
+	id     int     // The ID the object had in the schema
+}
+
+// propagateDelete updates objects that referenced a deleted object to match the actions the database performed.
+// Objects removed by a cascade are propagated in turn so the whole relation graph stays consistent, and the inverse
+// fields of the changed objects are updated
+func (m *schemaManager) propagateDelete(tx *Tx, target *schema, val Readable, valID int) {
+	// Objects removed by a cascade
+	removed := map[Readable]removal{}
+
+	for _, ref := range m.referrers(target) {
+		// The database removes the referencing rows, or sets their columns to null or removes their links
+		cascade := ref.pointer() && ref.onDelete == Cascade
+		clear := (ref.pointer() && ref.onDelete == SetNull) || (!ref.pointer() && ref.onDelete == Cascade)
+		if !cascade && !clear {
+			continue
+		}
+
+		for id, obj := range ref.source.objects {
+			field := reflect.ValueOf(obj.Object()).Elem().Field(ref.index)
+			if !ref.references(field, val, valID) {
+				continue
+			}
+			tx.touch(ref.source, id)
+
+			if cascade {
+				ref.source.remove(id)
+				m.syncInverses(ref.source, obj.Object())
+				removed[obj.Object()] = removal{source: ref.source, id: id}
+				continue
+			}
+
+			tx.recordField(obj.Object(), ref.index)
+			ref.drop(field, val, valID)
+
+			// The row matches the recorded state with the same change
+			ref.source.persistDrop(id, ref, val, valID)
+			m.syncInverses(ref.source, obj.Object())
+		}
+	}
+
+	// Propagate the objects that were removed by a cascade
+	for obj, r := range removed {
+		m.propagateDelete(tx, r.source, obj, r.id)
+	}
+}
+
+// references checks if a value of the relation field references an object. Lazy relations reference the object by
+// its ID or by the loaded object
+func (ref reference) references(field reflect.Value, val Readable, valID int) bool {
+	if ref.lazy {
+		for _, t := range getLazy(field).targets() {
+			if t.object == val || (t.object == nil && t.id == valID) {
+				return true
+			}
+		}
+		return false
+	} else if ref.pointer() {
+		return !field.IsNil() && field.Interface() == val
+	}
+
+	for i := 0; i < field.Len(); i++ {
+		if target, err := getLinkTarget(ref.field, field.Index(i)); err == nil && target == val {
+			return true
+		}
+	}
+	return false
+}
+
+// drop removes an object from a value of the relation field
+func (ref reference) drop(field reflect.Value, val Readable, valID int) {
+	if ref.lazy {
+		kept := []lazyTarget{}
+		for _, t := range getLazy(field).targets() {
+			if t.object != val && (t.object != nil || t.id != valID) {
+				kept = append(kept, t)
+			}
+		}
+
+		getLazy(field).setTargets(kept)
+		return
+	} else if ref.pointer() {
+		field.Set(reflect.Zero(field.Type()))
+		return
+	}
+
+	pruned := reflect.MakeSlice(field.Type(), 0, field.Len())
+	for i := 0; i < field.Len(); i++ {
+		if target, err := getLinkTarget(ref.field, field.Index(i)); err != nil || target != val {
+			pruned = reflect.Append(pruned, field.Index(i))
+		}
+	}
+	field.Set(pruned)
+}
+
 // manager holds all schemas locally so they can reference one another
 var manager schemaManager
 
-// GetObjectBySchema is used by read methods to get objects in other schemas
+// GetObjectBySchema is used by read methods to get objects in other schemas. GetObject looks up the schema by
+// type instead of by name
 func GetObjectBySchema(name string, id int) (Readable, error) {
 	// Get the schema from the manager
 	schema, err := manager.getSchema(name)
@@ -47,5 +194,170 @@ func GetObjectBySchema(name string, id int) (Readable, error) {
 	}
 
 	// Get the object
-	return schema.getByID(id)
+	obj, err := schema.getByID(id)
+	if err != nil && manager.deferred[name] {
+		// Objects in a schema that is not read yet are replaced after the cycle is read
+		return manager.placeholder(schema, id), nil
+	}
+
+	return obj, err
+}
+
+// getSchemaOf is a helper method that gets the schema registered for the type T, which must be a pointer to the
+// template struct of the schema
+func getSchemaOf[T Readable]() (*schema, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("type %v is not a pointer to a struct", t)
+	}
+
+	schema, err := manager.getSchema(t.Elem().Name())
+	if err != nil {
+		return nil, err
+	} else if reflect.TypeOf(schema.template) != t.Elem() {
+		// A struct with the same name from another package is registered
+		return nil, &UnregisteredSchemaError{Name: t.Elem().String()}
+	}
+
+	return schema, nil
+}
+
+// GetObject gets the object with the given ID from the schema registered for the type T
+func GetObject[T Readable](id int) (T, error) {
+	var obj T
+
+	schema, err := getSchemaOf[T]()
+	if err != nil {
+		return obj, err
+	}
+
+	val, err := schema.getByID(id)
+	if err != nil && manager.deferred[schema.name()] {
+		// Objects in a schema that is not read yet are replaced after the cycle is read
+		val, err = manager.placeholder(schema, id), nil
+	} else if err != nil {
+		return obj, err
+	}
+
+	// Cast the object to the generic type and return
+	obj, ok := val.(T)
+	if !ok {
+		return obj, fmt.Errorf("cannot cast object with given id to custom type")
+	}
+
+	return obj, nil
+}
+
+// GetWrapper gets a wrapper around the schema registered for the type T
+func GetWrapper[T Readable]() (*Wrapper[T], error) {
+	schema, err := getSchemaOf[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Wrapper[T]{schema: schema}, nil
+}
+
+// ReadAll reads every schema, reading schemas before the schemas that reference them. Schemas that reference
+// each other in a cycle are read with GetObjectBySchema returning placeholder objects for schemas that are not read
+// yet, and the placeholders are replaced with the objects that were read afterwards
+func ReadAll() error {
+	return manager.readAll()
+}
+
+// Referrer lists the objects in a schema that reference an object through a relation field
+type Referrer struct {
+	Schema  string     // The name of the referencing schema
+	Field   string     // The name of the relation field
+	IDs     []int      // The IDs of the referencing objects
+	Objects []Readable // The referencing objects, or nil for objects that are not loaded
+}
+
+// referencedBy finds the objects that reference an object. Schemas that were read are searched in memory, and other
+// schemas are searched in the database
+func (m *schemaManager) referencedBy(tx *Tx, target *schema, val Readable) ([]Referrer, error) {
+	referrers := []Referrer{}
+
+	if err := target.checkTx(tx); err != nil {
+		return referrers, err
+	}
+
+	id, err := target.getID(val)
+	if err != nil {
+		return referrers, err
+	}
+
+	for _, ref := range m.referrers(target) {
+		var ids []int
+		if ref.source.loaded {
+			ids = ref.source.referencingIDs(ref, val, id)
+		} else if ids, err = ref.source.readReferencingIDs(ref.source.conn(tx), ref, id); err != nil {
+			return referrers, err
+		}
+
+		if len(ids) == 0 {
+			continue
+		}
+
+		// Add the loaded referencing objects
+		referrer := Referrer{Schema: ref.source.name(), Field: ref.field.Name, IDs: ids}
+		for _, id := range ids {
+			obj, _ := ref.source.getByID(id)
+			referrer.Objects = append(referrer.Objects, obj)
+		}
+
+		referrers = append(referrers, referrer)
+	}
+
+	// Order the referrers by schema and field
+	sort.Slice(referrers, func(i, j int) bool {
+		if referrers[i].Schema != referrers[j].Schema {
+			return referrers[i].Schema < referrers[j].Schema
+		}
+		return referrers[i].Field < referrers[j].Field
+	})
+
+	return referrers, nil
+}
+
+// referencingIDs gets the IDs of the objects in the schema that reference an object through a relation field in memory
+func (s *schema) referencingIDs(ref reference, val Readable, valID int) []int {
+	ids := []int{}
+
+	for id, obj := range s.objects {
+		if ref.references(reflect.ValueOf(obj.Object()).Elem().Field(ref.index), val, valID) {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Ints(ids)
+	return ids
+}
+
+// readReferencingIDs reads the IDs of the rows in the schema that reference an object through a relation field
+func (s *schema) readReferencingIDs(q Querier, ref reference, valID int) ([]int, error) {
+	ids := []int{}
+
+	var query string
+	if ref.pointer() {
+		query = fmt.Sprintf("SELECT id FROM %v WHERE %v = %v ORDER BY id;", s.table, ref.name, valID)
+	} else {
+		query = fmt.Sprintf("SELECT DISTINCT %[1]vID FROM %[2]v WHERE %[3]v = %[4]v ORDER BY %[1]vID;", s.table, ref.junction, ref.name, valID)
+	}
+
+	rows, err := q.Query(query)
+	if err != nil {
+		return ids, err
+	}
+	defer rows.Close()
+
+	var id int
+	for rows.Next() {
+		if err := rows.Scan(&id); err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
 }
@@ -0,0 +1,122 @@
+package sql_wrapper_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	sql_wrapper "github.com/ethanbaker/sql-wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Types ----------
+
+// UnregisteredObject is never used to create a wrapper
+type UnregisteredObject struct {
+	Name string `sql:"Name" def:"VARCHAR(128)"`
+}
+
+// Read reads in UnregisteredObjects from an SQL query
+func (u UnregisteredObject) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	return map[int]sql_wrapper.Readable{}, nil
+}
+
+// ---------- Tests ----------
+
+func TestGetObject(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	obj := TestObject{Name: "Jack", Age: 19, Weather: Summer}
+
+	id, err := wrapper.Insert(&obj)
+	assert.Nil(err)
+
+	// The object should be found by its type
+	found, err := sql_wrapper.GetObject[*TestObject](id)
+	assert.Nil(err)
+	assert.Equal(&obj, found)
+
+	// A missing ID should return a typed error
+	_, err = sql_wrapper.GetObject[*TestObject](id + 1)
+	var notFound *sql_wrapper.ObjectNotFoundError
+	assert.True(errors.As(err, &notFound))
+	assert.Equal(id+1, notFound.ID)
+
+	// A type without a wrapper should return a typed error
+	_, err = sql_wrapper.GetObject[*UnregisteredObject](id)
+	var unregistered *sql_wrapper.UnregisteredSchemaError
+	assert.True(errors.As(err, &unregistered))
+	assert.Equal("UnregisteredObject", unregistered.Name)
+}
+
+func TestGetWrapper(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	obj := TestObject{Name: "John", Age: 20, Weather: Winter}
+
+	// The wrapper should share the schema of the registered wrapper
+	found, err := sql_wrapper.GetWrapper[*TestObject]()
+	assert.Nil(err)
+
+	id, err := found.Insert(&obj)
+	assert.Nil(err)
+
+	registered, err := wrapper.GetByID(id)
+	assert.Nil(err)
+	assert.Equal(&obj, registered)
+
+	// A type without a wrapper should return a typed error
+	_, err = sql_wrapper.GetWrapper[*UnregisteredObject]()
+	var unregistered *sql_wrapper.UnregisteredSchemaError
+	assert.True(errors.As(err, &unregistered))
+}
+
+func TestReferencedBy(t *testing.T) {
+	refSetup()
+	assert := assert.New(t)
+
+	writer := Writer{Name: "Jack"}
+	first := Article{Title: "First", Writer: sql_wrapper.RefTo(&writer)}
+	second := Article{Title: "Second"}
+	issue := Issue{Name: "Spring", Articles: sql_wrapper.RefListOf(&first)}
+
+	// Insert the objects
+	_, err := writerWrapper.Insert(&writer)
+	assert.Nil(err)
+
+	firstID, err := articleWrapper.Insert(&first)
+	assert.Nil(err)
+
+	_, err = articleWrapper.Insert(&second)
+	assert.Nil(err)
+
+	issueID, err := issueWrapper.Insert(&issue)
+	assert.Nil(err)
+
+	// Schemas that were not read should be searched in the database
+	referrers, err := writerWrapper.ReferencedBy(&writer)
+	assert.Nil(err)
+	assert.Equal([]sql_wrapper.Referrer{{Schema: "Article", Field: "Writer", IDs: []int{firstID}, Objects: []sql_wrapper.Readable{&first}}}, referrers)
+
+	referrers, err = articleWrapper.ReferencedBy(&second)
+	assert.Nil(err)
+	assert.Equal(0, len(referrers))
+
+	// Schemas that were read should be searched in memory
+	refSetupWrappers()
+	assert.Nil(writerWrapper.Read())
+	assert.Nil(articleWrapper.Read())
+	assert.Nil(issueWrapper.Read())
+
+	readArticle, err := articleWrapper.GetByID(firstID)
+	assert.Nil(err)
+
+	readIssue, err := issueWrapper.GetByID(issueID)
+	assert.Nil(err)
+
+	referrers, err = articleWrapper.ReferencedBy(readArticle)
+	assert.Nil(err)
+	assert.Equal([]sql_wrapper.Referrer{{Schema: "Issue", Field: "Articles", IDs: []int{issueID}, Objects: []sql_wrapper.Readable{readIssue}}}, referrers)
+}
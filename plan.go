@@ -0,0 +1,167 @@
+package sql_wrapper
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// fieldPlan is the metadata of a struct field, read from its tags once when the schema is created
+type fieldPlan struct {
+	field    reflect.StructField // The struct field
+	index    int                 // The index of the field in the struct
+	skip     bool                // Whether the field is not stored in the table (named '-' or an inverse field)
+	name     string              // The name of the column, or of the target column in the table of a slice relation
+	def      string              // The definition of the column of an attribute
+	rel      RelationType        // The relation of the field
+	lazy     bool                // Whether the field is a lazy relation
+	target   string              // The name of the schema the relation or inverse field references
+	cascade  bool                // Whether saving an object saves the targets of the relation
+	onDelete Action              // What the database does when the referenced row is deleted
+	onUpdate Action              // What the database does when the referenced row is updated
+	inverse  string              // The relation field in the target schema an inverse field mirrors
+
+	junction     string   // The table storing the links of a slice relation
+	through      int      // The index of the target field in the junction structs of a slice relation, or -1
+	extras       []string // The columns of the table of a slice relation after the target and source columns
+	extraDefs    []string // The definitions of the extra columns, with an empty definition for the position
+	extraIndexes []int    // The junction struct field of each extra column, or -1 for the position
+	position     string   // The position column of an ordered slice relation
+	ordered      bool     // Whether the slice relation keeps its order
+}
+
+// pointer checks if the field is a one-to-one or many-to-one relation stored in a column
+func (p fieldPlan) pointer() bool {
+	return p.rel == OneToOne || p.rel == ManyToOne
+}
+
+// list checks if the field is a one-to-many or many-to-many relation stored in another table
+func (p fieldPlan) list() bool {
+	return p.rel == OneToMany || p.rel == ManyToMany
+}
+
+// compile builds the field plan of the schema, validating the tags of every field
+func (s *schema) compile() error {
+	t := reflect.TypeOf(s.template)
+	s.table = t.Name()
+	s.plan = make([]fieldPlan, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		p, err := s.compileField(t.Field(i))
+		if err != nil {
+			return err
+		}
+		s.plan[i] = p
+	}
+
+	// Add the indexes declared on the struct
+	indexes, err := getIndexes(s.table, t)
+	if err != nil {
+		return err
+	}
+	s.indexes = indexes
+
+	return nil
+}
+
+// compileField is a helper method that builds the plan of one struct field
+func (s *schema) compileField(field reflect.StructField) (fieldPlan, error) {
+	p := fieldPlan{field: field, index: field.Index[0], rel: getRelation(field), lazy: isLazy(field.Type), through: -1}
+
+	name, err := getName(field)
+	if err != nil {
+		return p, err
+	}
+	p.name = name
+
+	// Fields with names '-' and inverse fields are not stored
+	inverse, ok := getInverse(field)
+	if ok {
+		p.inverse = inverse
+		p.target = getTarget(field)
+	}
+	if p.skip = name == "-" || ok; p.skip {
+		return p, nil
+	}
+
+	if p.rel == UndefinedRelationType {
+		// Only relations can save the objects they reference
+		if _, ok := field.Tag.Lookup("cascade"); ok {
+			return p, fmt.Errorf("tag 'cascade' cannot be used on field '%v' without a relation", field.Name)
+		}
+
+		p.def, err = getDefinition(field)
+		return p, err
+	}
+
+	p.target = getTarget(field)
+	if p.onDelete, p.onUpdate, err = getActions(field, p.rel); err != nil {
+		return p, err
+	}
+	if p.cascade, err = getCascade(field); err != nil {
+		return p, err
+	}
+
+	// Lazy relations must match the kind of their relation
+	if p.lazy && reflect.New(field.Type).Interface().(lazyRelation).many() != p.list() {
+		return p, fmt.Errorf("field '%v' with type %v cannot have a %v relation", field.Name, field.Type, p.rel)
+	}
+
+	// Only links stored in another table can keep an order
+	if _, ok := getPositionColumn(field); ok && p.pointer() {
+		return p, fmt.Errorf("tag 'ordered' cannot be used on field '%v' with a pointer relation", field.Name)
+	}
+
+	if !p.list() {
+		return p, nil
+	}
+
+	if p.name, err = getLinkColumn(field); err != nil {
+		return p, err
+	}
+	if p.extras, p.extraIndexes, err = getLinkExtras(field); err != nil {
+		return p, err
+	}
+	if target, ok := getJunctionStruct(field); ok {
+		p.through = target.Index[0]
+	}
+
+	for _, k := range p.extraIndexes {
+		def := ""
+		if k >= 0 {
+			if def, err = getDefinition(field.Type.Elem().Elem().Field(k)); err != nil {
+				return p, err
+			}
+		}
+		p.extraDefs = append(p.extraDefs, def)
+	}
+
+	p.junction = getJunction(s.table, field)
+	p.position, p.ordered = getPositionColumn(field)
+
+	return p, nil
+}
+
+// fieldPlan gets the plan of a struct field of the schema
+func (s *schema) fieldPlan(field reflect.StructField) fieldPlan {
+	return s.plan[field.Index[0]]
+}
+
+// namedPlan gets the plan of a struct field of the schema by the name of the field
+func (s *schema) namedPlan(name string) (fieldPlan, bool) {
+	field, ok := reflect.TypeOf(s.template).FieldByName(name)
+	if !ok || len(field.Index) != 1 {
+		return fieldPlan{}, false
+	}
+	return s.fieldPlan(field), true
+}
+
+// storedPlan gets the plan of a struct field that is stored in the database by the name of the field
+func (s *schema) storedPlan(name string) (fieldPlan, error) {
+	p, ok := s.namedPlan(name)
+	if !ok {
+		return fieldPlan{}, fmt.Errorf("field '%v' does not exist", name)
+	} else if p.skip {
+		return p, fmt.Errorf("field '%v' is not stored in the database", name)
+	}
+	return p, nil
+}
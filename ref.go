@@ -0,0 +1,547 @@
+package sql_wrapper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// lazyTarget is a target of a lazy relation field. The object is set once the target is loaded or assigned, and
+// takes precedence over the ID
+type lazyTarget struct {
+	id     int
+	object Readable
+}
+
+// lazyRelation is implemented by relation field types that store the IDs of their targets until they are loaded
+type lazyRelation interface {
+	targetType() reflect.Type
+	many() bool
+	targets() []lazyTarget
+	setTargets(targets []lazyTarget)
+}
+
+// lazyRelationType is the type of the lazyRelation interface
+var lazyRelationType = reflect.TypeOf((*lazyRelation)(nil)).Elem()
+
+// isLazy is a helper method that checks if a field type is a lazy relation
+func isLazy(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(lazyRelationType)
+}
+
+// getLazy is a helper method that gets the lazy relation stored in a field value
+func getLazy(v reflect.Value) lazyRelation {
+	return v.Addr().Interface().(lazyRelation)
+}
+
+// lazyIDs is a helper method that gets the IDs of the targets of a lazy relation field value
+func lazyIDs(target string, v reflect.Value) ([]int, error) {
+	ids := []int{}
+
+	schema, err := manager.getSchema(target)
+	if err != nil {
+		return ids, err
+	}
+
+	for _, target := range getLazy(v).targets() {
+		if target.object == nil {
+			ids = append(ids, target.id)
+			continue
+		}
+
+		id, err := schema.getID(target.object)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// loadTarget is a helper method that loads the object with the given ID from the schema of type T
+func loadTarget[T Readable](id int) (T, error) {
+	var obj T
+
+	schema, err := getSchemaOf[T]()
+	if err != nil {
+		return obj, err
+	}
+
+	val, err := schema.loadByID(nil, id)
+	if err != nil {
+		return obj, err
+	}
+
+	obj, ok := val.(T)
+	if !ok {
+		return obj, fmt.Errorf("cannot cast object with given id to custom type")
+	}
+	return obj, nil
+}
+
+// Ref is a many-to-one or one-to-one relation field that stores the ID of its target until it is loaded
+type Ref[T Readable] struct {
+	id     int
+	object T
+	valid  bool
+	loaded bool
+}
+
+// NewRef creates a reference to the object with the given ID
+func NewRef[T Readable](id int) Ref[T] {
+	return Ref[T]{id: id, valid: true}
+}
+
+// RefTo creates a reference to a loaded object
+func RefTo[T Readable](obj T) Ref[T] {
+	r := Ref[T]{}
+	r.Set(obj)
+
+	return r
+}
+
+// IsNil checks if the reference does not have a target
+func (r Ref[T]) IsNil() bool {
+	return !r.valid
+}
+
+// IsLoaded checks if the target of the reference is loaded
+func (r Ref[T]) IsLoaded() bool {
+	return r.loaded
+}
+
+// ID gets the ID of the target, or -1 if there is no target or the target is not saved
+func (r Ref[T]) ID() int {
+	if !r.valid {
+		return -1
+	} else if !r.loaded {
+		return r.id
+	}
+
+	ids, err := lazyIDs(r.targetType().Name(), reflect.ValueOf(&r).Elem())
+	if err != nil || len(ids) == 0 {
+		return -1
+	}
+	return ids[0]
+}
+
+// Set sets the target of the reference. Setting a nil object clears the reference
+func (r *Ref[T]) Set(obj T) {
+	if v := reflect.ValueOf(obj); !v.IsValid() || v.IsNil() {
+		*r = Ref[T]{}
+		return
+	}
+
+	*r = Ref[T]{object: obj, valid: true, loaded: true}
+}
+
+// Load gets the target of the reference, reading it from the database if it is not loaded
+func (r *Ref[T]) Load() (T, error) {
+	if !r.valid || r.loaded {
+		return r.object, nil
+	}
+
+	obj, err := loadTarget[T](r.id)
+	if err != nil {
+		return obj, err
+	}
+
+	r.object = obj
+	r.loaded = true
+
+	return obj, nil
+}
+
+func (r Ref[T]) targetType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem().Elem()
+}
+
+func (r Ref[T]) many() bool {
+	return false
+}
+
+func (r Ref[T]) targets() []lazyTarget {
+	if !r.valid {
+		return []lazyTarget{}
+	} else if r.loaded {
+		return []lazyTarget{{id: r.id, object: r.object}}
+	}
+	return []lazyTarget{{id: r.id}}
+}
+
+func (r *Ref[T]) setTargets(targets []lazyTarget) {
+	if len(targets) == 0 {
+		*r = Ref[T]{}
+		return
+	}
+
+	*r = Ref[T]{id: targets[0].id, valid: true}
+	if obj, ok := targets[0].object.(T); ok {
+		r.object = obj
+		r.loaded = true
+	}
+}
+
+// RefList is a one-to-many or many-to-many relation field that stores the IDs of its targets until they are loaded
+type RefList[T Readable] struct {
+	refs []Ref[T]
+}
+
+// NewRefList creates a list of references to the objects with the given IDs
+func NewRefList[T Readable](ids ...int) RefList[T] {
+	l := RefList[T]{}
+	for _, id := range ids {
+		l.refs = append(l.refs, NewRef[T](id))
+	}
+
+	return l
+}
+
+// RefListOf creates a list of references to loaded objects
+func RefListOf[T Readable](objs ...T) RefList[T] {
+	l := RefList[T]{}
+	l.Set(objs...)
+
+	return l
+}
+
+// Len gets the number of targets in the list
+func (l RefList[T]) Len() int {
+	return len(l.refs)
+}
+
+// IDs gets the IDs of the targets, with -1 for targets that are not saved
+func (l RefList[T]) IDs() []int {
+	ids := make([]int, len(l.refs))
+	for i, r := range l.refs {
+		ids[i] = r.ID()
+	}
+
+	return ids
+}
+
+// Add appends a target to the list
+func (l *RefList[T]) Add(obj T) {
+	if r := RefTo(obj); !r.IsNil() {
+		l.refs = append(l.refs, r)
+	}
+}
+
+// Set replaces the targets of the list
+func (l *RefList[T]) Set(objs ...T) {
+	l.refs = nil
+	for _, obj := range objs {
+		l.Add(obj)
+	}
+}
+
+// Load gets the targets of the list, reading the targets that are not loaded from the database
+func (l *RefList[T]) Load() ([]T, error) {
+	objs := make([]T, len(l.refs))
+	for i := range l.refs {
+		obj, err := l.refs[i].Load()
+		if err != nil {
+			return objs, err
+		}
+		objs[i] = obj
+	}
+
+	return objs, nil
+}
+
+func (l RefList[T]) targetType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem().Elem()
+}
+
+func (l RefList[T]) many() bool {
+	return true
+}
+
+func (l RefList[T]) targets() []lazyTarget {
+	targets := []lazyTarget{}
+	for _, r := range l.refs {
+		targets = append(targets, r.targets()...)
+	}
+
+	return targets
+}
+
+func (l *RefList[T]) setTargets(targets []lazyTarget) {
+	l.refs = nil
+	for _, target := range targets {
+		r := Ref[T]{}
+		r.setTargets([]lazyTarget{target})
+		l.refs = append(l.refs, r)
+	}
+}
+
+// fillLazy sets the IDs of the lazy relation fields of every object in the schema from the database
+func (s *schema) fillLazy(tx *Tx) error {
+	for _, p := range s.plan {
+		if !p.lazy || p.skip {
+			continue
+		}
+
+		ids, err := s.readLazyIDs(tx, p, -1)
+		if err != nil {
+			return err
+		}
+
+		for id, obj := range s.objects {
+			targets := []lazyTarget{}
+			for _, targetID := range ids[id] {
+				targets = append(targets, lazyTarget{id: targetID})
+			}
+
+			tx.recordField(obj.Object(), p.index)
+			getLazy(reflect.ValueOf(obj.Object()).Elem().Field(p.index)).setTargets(targets)
+		}
+	}
+
+	return nil
+}
+
+// readLazyIDs reads the IDs of the targets of a relation field for every object, or only for the object with the
+// given ID if it is not negative. Ordered relations are read in order of position
+func (s *schema) readLazyIDs(tx *Tx, p fieldPlan, id int) (map[int][]int, error) {
+	ids := map[int][]int{}
+
+	var query string
+	if p.pointer() {
+		query = fmt.Sprintf("SELECT id, %[1]v FROM %[2]v WHERE %[1]v IS NOT NULL", p.name, s.table)
+		if id >= 0 {
+			query += fmt.Sprintf(" AND id = %v", id)
+		}
+	} else {
+		query = fmt.Sprintf("SELECT %vID, %v FROM %v", s.table, p.name, p.junction)
+		if id >= 0 {
+			query += fmt.Sprintf(" WHERE %vID = %v", s.table, id)
+		}
+		if p.ordered {
+			query += " ORDER BY " + p.position
+		}
+	}
+
+	rows, err := s.conn(tx).Query(query + ";")
+	if err != nil {
+		return ids, err
+	}
+	defer rows.Close()
+
+	var (
+		objID    int
+		targetID int
+	)
+	for rows.Next() {
+		if err := rows.Scan(&objID, &targetID); err != nil {
+			return ids, err
+		}
+		ids[objID] = append(ids[objID], targetID)
+	}
+
+	return ids, rows.Err()
+}
+
+// loadByID gets the object with the given ID, reading it from the database if it is not in the schema. Relation
+// fields are read with it: lazy relations store the IDs of their targets, and other relations load their targets in turn
+func (s *schema) loadByID(tx *Tx, id int) (Readable, error) {
+	if obj, err := s.getByID(id); err == nil {
+		return obj, nil
+	}
+	return s.readRow(tx, id, "")
+}
+
+// readRow reads the row with the given ID into its object, adding a new object to the schema if it is not loaded.
+// Loaded objects are updated in place, and fields that are not stored keep their values
+func (s *schema) readRow(tx *Tx, id int, lock string) (Readable, error) {
+	if err := s.checkTx(tx); err != nil {
+		return nil, err
+	}
+
+	existing, loaded := s.objects[id]
+	tx.touch(s, id)
+
+	v := reflect.New(reflect.TypeOf(s.template)).Elem()
+	if loaded {
+		// Read into a copy so the object does not change if the read fails
+		v.Set(reflect.ValueOf(existing.Object()).Elem())
+	}
+
+	relations, err := s.readColumns(tx, id, v, lock)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := v.Addr().Interface().(Readable)
+	if loaded {
+		if err := s.readRelations(tx, id, v, relations); err != nil {
+			return nil, err
+		}
+
+		obj = existing.Object()
+		tx.recordFields(s, obj)
+		reflect.ValueOf(obj).Elem().Set(v)
+	} else {
+		// Add the object before its relations are loaded so references back to it resolve
+		s.add(id, obj)
+		if id >= s.nextID {
+			s.nextID = id + 1
+		}
+
+		if err := s.readRelations(tx, id, v, relations); err != nil {
+			s.remove(id)
+			return nil, err
+		}
+	}
+	s.persist(id)
+
+	// Update inverse fields that mirror the schema
+	manager.syncInverses(s, obj)
+	return obj, nil
+}
+
+// readColumns scans the columns of the row with the given ID directly into the fields of an object, and returns the
+// IDs stored in the columns of pointer relations by field index, with -1 for null. The lock clause is added to the
+// select statement if it is not empty
+func (s *schema) readColumns(tx *Tx, id int, v reflect.Value, lock string) (map[int]*int64, error) {
+	columns := []string{}
+	dest := []interface{}{}
+	relations := map[int]*int64{}
+
+	for _, p := range s.plan {
+		if p.skip {
+			continue
+		}
+
+		if p.rel == UndefinedRelationType {
+			columns = append(columns, p.name)
+			dest = append(dest, v.Field(p.index).Addr().Interface())
+		} else if p.pointer() {
+			relations[p.index] = new(int64)
+			columns = append(columns, fmt.Sprintf("IFNULL(%v, -1)", p.name))
+			dest = append(dest, relations[p.index])
+		}
+	}
+
+	if len(columns) == 0 {
+		columns = append(columns, "id")
+		dest = append(dest, new(int))
+	}
+
+	query := fmt.Sprintf("SELECT %v FROM %v WHERE id = %v", strings.Join(columns, ", "), s.table, id)
+	if lock != "" {
+		query += " " + lock
+	}
+
+	err := s.conn(tx).QueryRow(query + ";").Scan(dest...)
+	return relations, err
+}
+
+// readRelations sets the relation fields of the object with the given ID from the database. Lazy relations store the
+// IDs of their targets, and other relations load their targets
+func (s *schema) readRelations(tx *Tx, id int, v reflect.Value, relations map[int]*int64) error {
+	for _, p := range s.plan {
+		if p.skip || p.rel == UndefinedRelationType {
+			continue
+		}
+
+		// Junction structs are built from the links, which hold their extra columns
+		if target, ok := getJunctionStruct(p.field); ok {
+			if err := s.readJunctions(tx, id, p, target, v.Field(p.index)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Get the IDs of the targets
+		targetIDs := []int{}
+		if targetID, ok := relations[p.index]; ok {
+			if *targetID >= 0 {
+				targetIDs = append(targetIDs, int(*targetID))
+			}
+		} else {
+			ids, err := s.readLazyIDs(tx, p, id)
+			if err != nil {
+				return err
+			}
+			targetIDs = ids[id]
+		}
+
+		// Lazy relations only store the IDs
+		if p.lazy {
+			targets := []lazyTarget{}
+			for _, targetID := range targetIDs {
+				targets = append(targets, lazyTarget{id: targetID})
+			}
+
+			getLazy(v.Field(p.index)).setTargets(targets)
+			continue
+		}
+
+		schema, err := manager.getSchema(p.target)
+		if err != nil {
+			return err
+		}
+
+		v.Field(p.index).Set(reflect.Zero(p.field.Type))
+		for _, targetID := range targetIDs {
+			target, err := schema.loadByID(tx, targetID)
+			if err != nil {
+				return err
+			}
+
+			if p.list() {
+				v.Field(p.index).Set(reflect.Append(v.Field(p.index), reflect.ValueOf(target)))
+			} else {
+				v.Field(p.index).Set(reflect.ValueOf(target))
+			}
+		}
+	}
+
+	return nil
+}
+
+// readJunctions sets a slice relation field with junction structs from the links of the object with the given ID. The
+// target of each link is loaded and the extra columns are set on the fields they are stored from
+func (s *schema) readJunctions(tx *Tx, id int, p fieldPlan, target reflect.StructField, field reflect.Value) error {
+	rows, err := s.readLinks(s.conn(tx), id, p.field, "")
+	if err != nil {
+		return err
+	}
+
+	// Ordered relations keep the order of their positions
+	if p.ordered {
+		for k, index := range p.extraIndexes {
+			if index < 0 {
+				sort.SliceStable(rows, func(a, b int) bool { return rows[a].extras[k].(int) < rows[b].extras[k].(int) })
+			}
+		}
+	}
+
+	schema, err := manager.getSchema(p.target)
+	if err != nil {
+		return err
+	}
+
+	field.Set(reflect.Zero(p.field.Type))
+	for _, row := range rows {
+		linked, err := schema.loadByID(tx, row.target)
+		if err != nil {
+			return err
+		}
+
+		junction := reflect.New(p.field.Type.Elem().Elem())
+		junction.Elem().Field(target.Index[0]).Set(reflect.ValueOf(linked))
+		for k, index := range p.extraIndexes {
+			if index >= 0 {
+				junction.Elem().Field(index).Set(reflect.ValueOf(row.extras[k]))
+			}
+		}
+
+		field.Set(reflect.Append(field, junction))
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+package sql_wrapper_test
+
+import (
+	"database/sql"
+	"log"
+	"testing"
+
+	sql_wrapper "github.com/ethanbaker/sql-wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Types ----------
+
+// Writer is used as the target of a lazy reference
+type Writer struct {
+	Name string `sql:"Name" def:"VARCHAR(128)"`
+}
+
+// Read reads in Writers from an SQL query
+func (w Writer) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM Writer")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id   int
+		name string
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &name); err != nil {
+			return items, err
+		}
+
+		obj := Writer{Name: name}
+		items[id] = &obj
+	}
+
+	return items, nil
+}
+
+// Article is used to test lazy references
+type Article struct {
+	Title  string                   `sql:"Title" def:"VARCHAR(128)"`
+	Writer sql_wrapper.Ref[*Writer] `sql:"WriterID" rel:"many-to-one"`
+}
+
+// Read reads in Articles from an SQL query. Lazy references are set by the wrapper
+func (a Article) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT id, Title FROM Article")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id    int
+		title string
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &title); err != nil {
+			return items, err
+		}
+
+		obj := Article{Title: title}
+		items[id] = &obj
+	}
+
+	return items, nil
+}
+
+// Issue is used to test lazy reference lists
+type Issue struct {
+	Name     string                        `sql:"Name" def:"VARCHAR(128)"`
+	Articles sql_wrapper.RefList[*Article] `sql:"ArticleID" rel:"many-to-many" ordered:""`
+}
+
+// Read reads in Issues from an SQL query. Lazy references are set by the wrapper
+func (i Issue) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM Issue")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id   int
+		name string
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &name); err != nil {
+			return items, err
+		}
+
+		obj := Issue{Name: name}
+		items[id] = &obj
+	}
+
+	return items, nil
+}
+
+// ---------- Globals ----------
+
+var writerWrapper *sql_wrapper.Wrapper[*Writer]
+var articleWrapper *sql_wrapper.Wrapper[*Article]
+var issueWrapper *sql_wrapper.Wrapper[*Issue]
+
+// ---------- Tests ----------
+
+func TestLazyLoad(t *testing.T) {
+	refSetup()
+	assert := assert.New(t)
+
+	writer := Writer{Name: "Jack"}
+	first := Article{Title: "First", Writer: sql_wrapper.RefTo(&writer)}
+	second := Article{Title: "Second", Writer: sql_wrapper.RefTo(&writer)}
+	issue := Issue{Name: "Spring", Articles: sql_wrapper.RefListOf(&second, &first)}
+
+	// Insert the objects
+	writerID, err := writerWrapper.Insert(&writer)
+	assert.Nil(err)
+
+	firstID, err := articleWrapper.Insert(&first)
+	assert.Nil(err)
+
+	secondID, err := articleWrapper.Insert(&second)
+	assert.Nil(err)
+
+	issueID, err := issueWrapper.Insert(&issue)
+	assert.Nil(err)
+
+	// Only read the issues into new wrappers
+	refSetupWrappers()
+	assert.Nil(issueWrapper.Read())
+
+	readIssue, err := issueWrapper.GetByID(issueID)
+	assert.Nil(err)
+	assert.Equal([]int{secondID, firstID}, readIssue.Articles.IDs())
+
+	// The articles should not be loaded until they are requested
+	_, err = articleWrapper.GetByID(firstID)
+	assert.NotNil(err)
+
+	articles, err := readIssue.Articles.Load()
+	assert.Nil(err)
+	assert.Equal(2, len(articles))
+	assert.Equal("Second", articles[0].Title)
+	assert.Equal("First", articles[1].Title)
+
+	// The loaded articles should be in the wrapper
+	readArticle, err := articleWrapper.GetByID(firstID)
+	assert.Nil(err)
+	assert.Equal(articles[1], readArticle)
+
+	// The writer should only store its ID until it is loaded
+	assert.False(readArticle.Writer.IsLoaded())
+	assert.Equal(writerID, readArticle.Writer.ID())
+
+	readWriter, err := readArticle.Writer.Load()
+	assert.Nil(err)
+	assert.Equal("Jack", readWriter.Name)
+}
+
+func TestLazyUpdate(t *testing.T) {
+	refSetup()
+	assert := assert.New(t)
+
+	jack := Writer{Name: "Jack"}
+	john := Writer{Name: "John"}
+	article := Article{Title: "First", Writer: sql_wrapper.RefTo(&jack)}
+
+	// Insert the objects
+	_, err := writerWrapper.Insert(&jack)
+	assert.Nil(err)
+
+	johnID, err := writerWrapper.Insert(&john)
+	assert.Nil(err)
+
+	articleID, err := articleWrapper.Insert(&article)
+	assert.Nil(err)
+
+	// Change the writer of the article
+	article.Writer.Set(&john)
+	assert.Nil(articleWrapper.Update(&article))
+
+	var writerID int
+	assert.Nil(database.QueryRow("SELECT WriterID FROM Article WHERE id = ?", articleID).Scan(&writerID))
+	assert.Equal(johnID, writerID)
+
+	// Deleting the writer should delete the article that references it
+	assert.Nil(writerWrapper.Delete(&john))
+
+	_, err = articleWrapper.GetID(&article)
+	assert.NotNil(err)
+}
+
+// ---------- Test Setup ----------
+
+func refSetup() {
+	// Drop the current wrapper
+	dropTables()
+
+	refSetupWrappers()
+}
+
+func refSetupWrappers() {
+	// Create the new wrappers
+	var err error
+	writerWrapper, err = sql_wrapper.NewWrapper[*Writer](database, Writer{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	articleWrapper, err = sql_wrapper.NewWrapper[*Article](database, Article{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	issueWrapper, err = sql_wrapper.NewWrapper[*Issue](database, Issue{})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,146 @@
+package sql_wrapper
+
+import (
+	"database/sql"
+	"reflect"
+)
+
+// reload reads the row of an object from the database into the object. Fields that are not stored keep their values.
+// If the row no longer exists, the object is removed from the schema
+func (s *schema) reload(tx *Tx, val Readable) error {
+	obj, err := s.validate(val)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.readRow(tx, obj.GetID(), "")
+	if err == sql.ErrNoRows {
+		s.evict(tx, obj.GetID())
+		return &ObjectNotFoundError{Schema: s.name(), ID: obj.GetID()}
+	}
+	return err
+}
+
+// evict is a helper method that removes an object whose row no longer exists and updates objects that referenced it
+func (s *schema) evict(tx *Tx, id int) {
+	obj, ok := s.objects[id]
+	if !ok {
+		return
+	}
+
+	tx.touch(s, id)
+	s.remove(id)
+	manager.syncInverses(s, obj.Object())
+	manager.propagateDelete(tx, s, obj.Object(), id)
+}
+
+// refresh reads the table again with the template's Read method. Objects that are already in the schema have their
+// stored fields updated in place so references to them stay valid, and objects whose rows no longer exist are removed
+func (s *schema) refresh(tx *Tx) error {
+	if err := s.checkTx(tx); err != nil {
+		return err
+	}
+
+	items, err := s.readItems(tx)
+	if err != nil {
+		return err
+	}
+
+	tx.touchAll(s)
+	for id := range items {
+		tx.touch(s, id)
+	}
+
+	// Map the objects that were read to the objects they replace
+	existing := map[Readable]Readable{}
+	for id, val := range items {
+		if obj, ok := s.objects[id]; ok && obj.Object() != val {
+			existing[val] = obj.Object()
+		}
+	}
+
+	// Remove the objects whose rows no longer exist
+	for id := range s.objects {
+		if _, ok := items[id]; !ok {
+			s.evict(tx, id)
+		}
+	}
+
+	// Update the existing objects and add the new ones
+	for id, val := range items {
+		if obj, ok := existing[val]; ok {
+			// Only the stored fields are read, so fields that are not stored keep their values like in reload
+			tx.recordFields(s, obj)
+
+			v := reflect.ValueOf(obj).Elem()
+			for _, p := range s.plan {
+				if !p.skip {
+					v.Field(p.index).Set(reflect.ValueOf(val).Elem().Field(p.index))
+				}
+			}
+		} else {
+			s.add(id, val)
+		}
+
+		if id >= s.nextID {
+			s.nextID = id + 1
+		}
+	}
+
+	// Objects that were read can reference each other, so point their relations at the existing objects
+	for _, obj := range s.objects {
+		s.replaceTargets(tx, obj.Object(), s.name(), existing)
+	}
+
+	// Set the IDs of lazy relations and restore the order of ordered relations
+	if err := s.fillLazy(tx); err != nil {
+		return err
+	}
+	s.loaded = true
+
+	if err := s.orderLinks(tx); err != nil {
+		return err
+	}
+	s.persistAll()
+
+	// Update inverse fields that mirror the schema
+	return manager.refreshInverses(s)
+}
+
+// replaceTargets is a helper method that replaces the targets of the relation fields of an object that reference
+// the named schema with the objects they map to
+func (s *schema) replaceTargets(tx *Tx, obj Readable, target string, replaced map[Readable]Readable) {
+	if len(replaced) == 0 {
+		return
+	}
+
+	for _, p := range s.plan {
+		if p.skip || p.lazy || p.target != target {
+			continue
+		}
+
+		tx.recordField(obj, p.index)
+		field := reflect.ValueOf(obj).Elem().Field(p.index)
+		if p.pointer() {
+			if target, ok := field.Interface().(Readable); ok && !field.IsNil() && replaced[target] != nil {
+				field.Set(reflect.ValueOf(replaced[target]))
+			}
+			continue
+		}
+
+		through, junction := getJunctionStruct(p.field)
+		for i := 0; i < field.Len(); i++ {
+			elem := field.Index(i)
+			if junction {
+				if elem.IsNil() {
+					continue
+				}
+				elem = elem.Elem().FieldByIndex(through.Index)
+			}
+
+			if target, ok := elem.Interface().(Readable); ok && !elem.IsNil() && replaced[target] != nil {
+				elem.Set(reflect.ValueOf(replaced[target]))
+			}
+		}
+	}
+}
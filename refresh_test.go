@@ -0,0 +1,86 @@
+package sql_wrapper_test
+
+import (
+	"testing"
+
+	sql_wrapper "github.com/ethanbaker/sql-wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Tests ----------
+
+func TestReload(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	obj := TestObject{Name: "Jack", Age: 20, Weather: Summer, Hidden: "abc"}
+	id, err := wrapper.Insert(&obj)
+	assert.Nil(err)
+
+	// Change the row behind the wrapper's back
+	_, err = database.Exec("UPDATE TestObject SET Name = 'John', Age = 21 WHERE id = ?", id)
+	assert.Nil(err)
+
+	// Reloading updates the object in place and keeps fields that are not stored
+	assert.Nil(wrapper.Reload(&obj))
+	assert.Equal("John", obj.Name)
+	assert.Equal(21, obj.Age)
+	assert.Equal("abc", obj.Hidden)
+
+	got, err := wrapper.GetByID(id)
+	assert.Nil(err)
+	assert.True(got == &obj)
+
+	// Reloading an object whose row was deleted removes it
+	_, err = database.Exec("DELETE FROM TestObject WHERE id = ?", id)
+	assert.Nil(err)
+
+	var notFound *sql_wrapper.ObjectNotFoundError
+	assert.ErrorAs(wrapper.Reload(&obj), &notFound)
+
+	_, err = wrapper.GetID(&obj)
+	assert.NotNil(err)
+}
+
+func TestRefresh(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	first := TestObject{Name: "Jack", Age: 20, Weather: Summer, Hidden: "abc"}
+	second := TestObject{Name: "Jill", Age: 21, Weather: Autumn}
+	firstID, err := wrapper.Insert(&first)
+	assert.Nil(err)
+	secondID, err := wrapper.Insert(&second)
+	assert.Nil(err)
+
+	// Change the table behind the wrapper's back
+	_, err = database.Exec("UPDATE TestObject SET Age = 30 WHERE id = ?", firstID)
+	assert.Nil(err)
+	_, err = database.Exec("DELETE FROM TestObject WHERE id = ?", secondID)
+	assert.Nil(err)
+	_, err = database.Exec("INSERT INTO TestObject (id, Name, Age, Weather) VALUES (?, 'John', 22, 'Winter')", secondID+1)
+	assert.Nil(err)
+
+	assert.Nil(wrapper.Refresh())
+
+	// Existing objects are updated in place, and fields that are not stored keep their values
+	assert.Equal(30, first.Age)
+	assert.Equal("abc", first.Hidden)
+	got, err := wrapper.GetByID(firstID)
+	assert.Nil(err)
+	assert.True(got == &first)
+
+	// Objects whose rows were deleted are removed and new rows are added
+	_, err = wrapper.GetID(&second)
+	assert.NotNil(err)
+
+	third, err := wrapper.GetByID(secondID + 1)
+	assert.Nil(err)
+	assert.Equal("John", third.Name)
+
+	// New objects get IDs after the rows that were read
+	fourth := TestObject{Name: "Jane", Weather: Spring}
+	fourthID, err := wrapper.Insert(&fourth)
+	assert.Nil(err)
+	assert.Equal(secondID+2, fourthID)
+}
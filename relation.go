@@ -1,5 +1,11 @@
 package sql_wrapper
 
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
 // RelationType represents different foreign key relationships
 type RelationType string
 
@@ -10,3 +16,369 @@ const (
 	ManyToOne             RelationType = "many-to-one"
 	ManyToMany            RelationType = "many-to-many"
 )
+
+// Action represents what the database does to a foreign key when the referenced row changes
+type Action string
+
+const (
+	UndefinedAction Action = ""
+	Cascade         Action = "CASCADE"
+	SetNull         Action = "SET NULL"
+	Restrict        Action = "RESTRICT"
+	NoAction        Action = "NO ACTION"
+)
+
+// relationField is a helper method that gets the plan of a relation field of the schema by its struct field name
+func (s *schema) relationField(name string) (fieldPlan, error) {
+	p, ok := s.namedPlan(name)
+	if !ok {
+		return p, fmt.Errorf("schema '%v' does not have field '%v'", s.table, name)
+	}
+
+	if p.rel == UndefinedRelationType {
+		return p, fmt.Errorf("field '%v' in schema '%v' is not a relation", name, s.table)
+	} else if p.inverse != "" {
+		return p, fmt.Errorf("field '%v' in schema '%v' is an inverse field and cannot be changed directly", name, s.table)
+	} else if p.lazy {
+		return p, fmt.Errorf("field '%v' in schema '%v' is a lazy field and is changed with its Set method", name, s.table)
+	}
+
+	return p, nil
+}
+
+// relationValue is a helper method that converts a value to the element type of a relation field
+func relationValue(field reflect.StructField, value interface{}) (reflect.Value, error) {
+	t := field.Type
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || !v.Type().AssignableTo(t) {
+		return v, fmt.Errorf("value of type %T cannot be used in field '%v' of type %v", value, field.Name, field.Type)
+	} else if v.IsNil() {
+		return v, fmt.Errorf("cannot use nil value in field '%v'", field.Name)
+	}
+
+	return v, nil
+}
+
+// addRelation appends a value to a slice relation field of an object and only inserts the new link
+func (s *schema) addRelation(tx *Tx, val Readable, name string, value interface{}) error {
+	obj, p, err := s.validateRelation(val, name)
+	if err != nil {
+		return err
+	} else if !p.list() {
+		return fmt.Errorf("cannot add to field '%v' with %v relation, use SetRelation instead", name, p.rel)
+	}
+
+	v, err := relationValue(p.field, value)
+	if err != nil {
+		return err
+	}
+
+	// Add the value to the object
+	slice := reflect.ValueOf(obj.Object()).Elem().Field(p.index)
+	old := slice.Interface()
+	slice.Set(reflect.Append(slice, v))
+
+	// Insert the link of the new element
+	rows, err := s.links(p.field, slice, slice.Len()-1)
+	if err != nil {
+		slice.Set(reflect.ValueOf(old))
+		return err
+	}
+
+	str, err := s.insertLinksSQL(obj.GetID(), p.field, rows)
+	if err != nil {
+		slice.Set(reflect.ValueOf(old))
+		return err
+	}
+
+	return s.execRelation(tx, obj.GetID(), p.index, slice, old, false, statements(str))
+}
+
+// removeRelation removes a value from a relation field of an object and only deletes its link. The value can be an
+// element of the field or the target object of a junction struct
+func (s *schema) removeRelation(tx *Tx, val Readable, name string, value interface{}) error {
+	obj, p, err := s.validateRelation(val, name)
+	if err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("cannot remove nil value from field '%v'", name)
+	}
+
+	target, ok := value.(Readable)
+	if !ok {
+		return fmt.Errorf("cannot cast value as Readable")
+	}
+
+	current := reflect.ValueOf(obj.Object()).Elem().Field(p.index)
+	old := current.Interface()
+
+	// Pointer relations are cleared when they point to the value
+	if p.pointer() {
+		if current.IsNil() || current.Pointer() != v.Pointer() {
+			return fmt.Errorf("field '%v' does not contain the value", name)
+		}
+
+		current.Set(reflect.Zero(p.field.Type))
+		return s.execRelation(tx, obj.GetID(), p.index, current, old, true, statements(statement{query: fmt.Sprintf("UPDATE %v SET %v = NULL WHERE id = %v;", s.table, p.name, obj.GetID())}))
+	}
+
+	// Get the ID of the linked object
+	schema, err := manager.getSchema(p.target)
+	if err != nil {
+		return err
+	}
+
+	kept := reflect.MakeSlice(p.field.Type, 0, current.Len())
+	targetID := -1
+	for i := 0; i < current.Len(); i++ {
+		elem := current.Index(i)
+
+		linked, err := getLinkTarget(p.field, elem)
+		if err != nil {
+			return err
+		}
+
+		if elem.Pointer() == v.Pointer() || reflect.ValueOf(linked).Pointer() == v.Pointer() {
+			if targetID, err = schema.getID(linked); err != nil {
+				return err
+			}
+			continue
+		}
+		kept = reflect.Append(kept, elem)
+	}
+
+	if targetID < 0 {
+		if _, err := schema.getID(target); err != nil {
+			return err
+		}
+		return fmt.Errorf("field '%v' does not contain the value", name)
+	}
+
+	// Remove the value from the object and delete its link
+	current.Set(kept)
+	return s.execRelation(tx, obj.GetID(), p.index, current, old, false, statements(statement{query: fmt.Sprintf("DELETE FROM %v WHERE %vID = %v AND %v = %v;", p.junction, s.table, obj.GetID(), p.name, targetID)}))
+}
+
+// setRelation replaces the values of a relation field of an object and only changes the links that differ. Pointer
+// relations accept zero or one value
+func (s *schema) setRelation(tx *Tx, val Readable, name string, values []interface{}) error {
+	obj, p, err := s.validateRelation(val, name)
+	if err != nil {
+		return err
+	}
+
+	current := reflect.ValueOf(obj.Object()).Elem().Field(p.index)
+	old := current.Interface()
+
+	// Pointer relations update the column of the object
+	if p.pointer() {
+		if len(values) > 1 {
+			return fmt.Errorf("cannot set %v values in field '%v' with %v relation", len(values), name, p.rel)
+		}
+
+		if len(values) == 0 {
+			current.Set(reflect.Zero(p.field.Type))
+			return s.execRelation(tx, obj.GetID(), p.index, current, old, true, statements(statement{query: fmt.Sprintf("UPDATE %v SET %v = NULL WHERE id = %v;", s.table, p.name, obj.GetID())}))
+		}
+
+		v, err := relationValue(p.field, values[0])
+		if err != nil {
+			return err
+		}
+
+		schema, err := manager.getSchema(p.target)
+		if err != nil {
+			return err
+		}
+
+		targetID, err := schema.getID(v.Interface().(Readable))
+		if err != nil {
+			return err
+		}
+
+		current.Set(v)
+		return s.execRelation(tx, obj.GetID(), p.index, current, old, true, statements(statement{query: fmt.Sprintf("UPDATE %v SET %v = %v WHERE id = %v;", s.table, p.name, targetID, obj.GetID())}))
+	}
+
+	// Slice relations are replaced and only the changed links are written
+	slice := reflect.MakeSlice(p.field.Type, 0, len(values))
+	for _, value := range values {
+		v, err := relationValue(p.field, value)
+		if err != nil {
+			return err
+		}
+		slice = reflect.Append(slice, v)
+	}
+
+	current.Set(slice)
+
+	// The changed links are found with the current links locked in the transaction of the change
+	return s.execRelation(tx, obj.GetID(), p.index, current, old, true, func(q Querier) ([]statement, error) {
+		return s.updateLinksSQL(q, obj.GetID(), p.field, current)
+	})
+}
+
+// validateRelation is a helper method that gets the registered object and the plan of the relation field used to
+// change a relation
+func (s *schema) validateRelation(val Readable, name string) (identifiableWrapper, fieldPlan, error) {
+	p, err := s.relationField(name)
+	if err != nil {
+		return identifiableWrapper{}, p, err
+	}
+
+	obj, err := s.validate(val)
+	if err != nil {
+		return identifiableWrapper{}, p, err
+	} else if obj.GetID() < 0 {
+		return identifiableWrapper{}, p, fmt.Errorf("object does not have valid id")
+	}
+
+	return obj, p, nil
+}
+
+// statements is a helper method that builds the statements of a relation change that do not read the database
+func statements(strs ...statement) func(Querier) ([]statement, error) {
+	return func(Querier) ([]statement, error) {
+		return strs, nil
+	}
+}
+
+// execRelation is a helper method that builds and executes the statements of a relation change in a transaction. The
+// field is restored to its old value if the statements fail. Whole is set if the statements write the entire field
+// rather than a single link
+func (s *schema) execRelation(tx *Tx, id int, index int, field reflect.Value, old interface{}, whole bool, build func(Querier) ([]statement, error)) error {
+	// Start a transaction in the database
+	t, err := s.begin(tx)
+	if err != nil {
+		field.Set(reflect.ValueOf(old))
+		return err
+	}
+
+	strs, err := build(t)
+	if err != nil {
+		t.Rollback()
+		field.Set(reflect.ValueOf(old))
+		return err
+	}
+
+	for _, str := range strs {
+		if _, err = t.Exec(str.query, str.args...); err != nil {
+			t.Rollback()
+			field.Set(reflect.ValueOf(old))
+			return err
+		}
+	}
+
+	if err = t.Commit(); err != nil {
+		field.Set(reflect.ValueOf(old))
+		return err
+	}
+
+	// Rolling back the transaction the change ran in restores the field
+	tx.touch(s, id)
+	if obj, ok := s.objects[id]; ok {
+		tx.recordValue(obj.Object(), index, reflect.ValueOf(old))
+	}
+
+	// The field matches the database if the whole field was written or it had no other pending changes. Otherwise its
+	// other changes stay pending, and the rest of the state is kept either way
+	if whole || s.cleanField(id, index, old) {
+		s.persistField(id, index)
+	}
+
+	// Update inverse fields that mirror the schema
+	if obj, ok := s.objects[id]; ok {
+		manager.syncInverses(s, obj.Object())
+	}
+	return nil
+}
+
+// foreignKey is a foreign key constraint that exists on a column of a table
+type foreignKey struct {
+	name     string // The name of the constraint
+	onDelete Action // The ON DELETE action of the constraint
+	onUpdate Action // The ON UPDATE action of the constraint
+}
+
+// sameAction is a helper method that checks if two actions behave the same. InnoDB treats RESTRICT as NO ACTION
+func sameAction(a Action, b Action) bool {
+	if a == Restrict {
+		a = NoAction
+	}
+	if b == Restrict {
+		b = NoAction
+	}
+	return a == b
+}
+
+// readForeignKeys reads the foreign keys that currently exist on a table, keyed by their column
+func readForeignKeys(tx *sql.Tx, table string) (map[string]foreignKey, error) {
+	keys := map[string]foreignKey{}
+
+	rows, err := tx.Query("SELECT k.COLUMN_NAME, k.CONSTRAINT_NAME, r.DELETE_RULE, r.UPDATE_RULE FROM information_schema.KEY_COLUMN_USAGE k JOIN information_schema.REFERENTIAL_CONSTRAINTS r ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.TABLE_NAME = k.TABLE_NAME AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME WHERE k.TABLE_SCHEMA = DATABASE() AND k.TABLE_NAME = ? AND k.REFERENCED_TABLE_NAME IS NOT NULL;", table)
+	if err != nil {
+		return keys, err
+	}
+	defer rows.Close()
+
+	var (
+		column string
+		key    foreignKey
+	)
+	for rows.Next() {
+		if err := rows.Scan(&column, &key.name, &key.onDelete, &key.onUpdate); err != nil {
+			return keys, err
+		}
+		keys[column] = key
+	}
+
+	return keys, rows.Err()
+}
+
+// migrateForeignKeysSQL creates strings that will replace the foreign keys of relation fields whose actions differ
+// from the ones in their tags. Tables created before the tags changed keep their old actions otherwise, since tables
+// are only created if they do not exist
+func (s *schema) migrateForeignKeysSQL(tx *sql.Tx) ([]string, error) {
+	statements := []string{}
+	existing := map[string]map[string]foreignKey{}
+
+	for _, p := range s.plan {
+		if p.skip || p.rel == UndefinedRelationType {
+			continue
+		}
+
+		// Pointer relations store their key in the table, and slice relations in the table of their links
+		table := s.table
+		if p.list() {
+			table = p.junction
+		}
+
+		keys, ok := existing[table]
+		if !ok {
+			var err error
+			if keys, err = readForeignKeys(tx, table); err != nil {
+				return statements, err
+			}
+			existing[table] = keys
+		}
+
+		key, ok := keys[p.name]
+		if !ok || (sameAction(key.onDelete, p.onDelete) && sameAction(key.onUpdate, p.onUpdate)) {
+			continue
+		}
+
+		statements = append(statements,
+			fmt.Sprintf("ALTER TABLE %v DROP FOREIGN KEY %v;", table, key.name),
+			fmt.Sprintf("ALTER TABLE %v ADD FOREIGN KEY (%v) REFERENCES %v(id) ON DELETE %v ON UPDATE %v;", table, p.name, p.target, p.onDelete, p.onUpdate),
+		)
+	}
+
+	return statements, nil
+}
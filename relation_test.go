@@ -69,7 +69,7 @@ func (t ReferenceObject) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error)
 	}
 
 	// Query the related elements
-	rows, err = db.Query("SELECT * FROM ReferenceObjectTestObject")
+	rows, err = db.Query("SELECT * FROM ReferenceObjectOneToMany")
 	if err != nil {
 		return items, err
 	}
@@ -110,9 +110,212 @@ func (t ReferenceObject) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error)
 	return items, nil
 }
 
+// ActionObject is used to test configurable foreign key actions
+type ActionObject struct {
+	Nullable   *TestObject `sql:"NullableID" rel:"many-to-one" ondelete:"set null"`
+	Restricted *TestObject `sql:"RestrictedID" rel:"many-to-one" ondelete:"restrict"`
+}
+
+// Read reads in ActionObjects from an SQL query
+func (t ActionObject) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM ActionObject")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id           int
+		nullableID   sql.NullInt64
+		restrictedID sql.NullInt64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &nullableID, &restrictedID); err != nil {
+			return items, err
+		}
+
+		obj := ActionObject{}
+
+		// Get the referenced objects from another schema if they are present
+		if nullableID.Valid {
+			readable, err := sql_wrapper.GetObjectBySchema("TestObject", int(nullableID.Int64))
+			if err != nil {
+				return items, err
+			}
+			obj.Nullable = readable.(*TestObject)
+		}
+		if restrictedID.Valid {
+			readable, err := sql_wrapper.GetObjectBySchema("TestObject", int(restrictedID.Int64))
+			if err != nil {
+				return items, err
+			}
+			obj.Restricted = readable.(*TestObject)
+		}
+
+		items[id] = &obj
+	}
+
+	return items, nil
+}
+
+// ChainObject is used to test cascades through multiple relations
+type ChainObject struct {
+	Reference *ReferenceObject `sql:"ReferenceID" rel:"many-to-one"`
+}
+
+// Read reads in ChainObjects from an SQL query
+func (t ChainObject) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM ChainObject")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id          int
+		referenceID int
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &referenceID); err != nil {
+			return items, err
+		}
+
+		// Get the referenced object from another schema
+		readable, err := sql_wrapper.GetObjectBySchema("ReferenceObject", referenceID)
+		if err != nil {
+			return items, err
+		}
+
+		obj := ChainObject{Reference: readable.(*ReferenceObject)}
+		items[id] = &obj
+	}
+
+	return items, nil
+}
+
+// Category is used to test self-referential relations
+type Category struct {
+	Name     string      `sql:"Name" def:"VARCHAR(128)"`
+	Parent   *Category   `sql:"ParentID" rel:"many-to-one"`
+	Children []*Category `inverse:"Parent"`
+}
+
+// Read reads in Categories from an SQL query
+func (c Category) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+	parents := map[int]int{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM Category")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id       int
+		name     string
+		parentID sql.NullInt64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &name, &parentID); err != nil {
+			return items, err
+		}
+
+		obj := Category{Name: name}
+		items[id] = &obj
+
+		if parentID.Valid {
+			parents[id] = int(parentID.Int64)
+		}
+	}
+
+	// Link each category to its parent once every row is read
+	for id, parentID := range parents {
+		items[id].(*Category).Parent = items[parentID].(*Category)
+	}
+
+	return items, nil
+}
+
+// Member is used to test multiple relations to the same type
+type Member struct {
+	Name      string    `sql:"Name" def:"VARCHAR(128)"`
+	Followers []*Member `sql:"FollowerID" rel:"many-to-many"`
+	Following []*Member `sql:"FollowingID" rel:"many-to-many" through:"MemberFollows"`
+}
+
+// Read reads in Members from an SQL query
+func (m Member) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	items := map[int]sql_wrapper.Readable{}
+
+	// Get the main elements
+	rows, err := db.Query("SELECT * FROM Member")
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	// Read for each row
+	var (
+		id   int
+		name string
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &name); err != nil {
+			return items, err
+		}
+
+		obj := Member{Name: name}
+		items[id] = &obj
+	}
+
+	// Query the related elements of both relations
+	for _, table := range []string{"MemberFollowers", "MemberFollows"} {
+		rows, err = db.Query("SELECT * FROM " + table)
+		if err != nil {
+			return items, err
+		}
+		defer rows.Close()
+
+		var (
+			memberID int
+			targetID int
+		)
+		for rows.Next() {
+			if err := rows.Scan(&memberID, &targetID); err != nil {
+				return items, err
+			}
+
+			member := items[memberID].(*Member)
+			target := items[targetID].(*Member)
+			if table == "MemberFollowers" {
+				member.Followers = append(member.Followers, target)
+			} else {
+				member.Following = append(member.Following, target)
+			}
+		}
+	}
+
+	return items, nil
+}
+
 // ---------- Globals ----------
 
 var referenceWrapper *sql_wrapper.Wrapper[*ReferenceObject]
+var actionWrapper *sql_wrapper.Wrapper[*ActionObject]
+var chainWrapper *sql_wrapper.Wrapper[*ChainObject]
+var categoryWrapper *sql_wrapper.Wrapper[*Category]
+var memberWrapper *sql_wrapper.Wrapper[*Member]
 
 // ---------- Tests ----------
 
@@ -178,8 +381,8 @@ func TestInsertWithForeignRelation(t *testing.T) {
 	assert.False(rows.Next())
 	assert.Nil(rows.Err())
 
-	// Test that the SQL ReferenceObjectTestObject database has the right entries
-	rows, err = database.Query("SELECT * FROM ReferenceObjectTestObject")
+	// Test that the SQL ReferenceObjectOneToMany database has the right entries
+	rows, err = database.Query("SELECT * FROM ReferenceObjectOneToMany")
 	assert.Nil(err)
 	defer rows.Close()
 
@@ -290,8 +493,8 @@ func TestUpdateWithForeignRelation(t *testing.T) {
 	assert.False(rows.Next())
 	assert.Nil(rows.Err())
 
-	// Test that the SQL ReferenceObjectTestObject database has the right entries
-	rows, err = database.Query("SELECT * FROM ReferenceObjectTestObject")
+	// Test that the SQL ReferenceObjectOneToMany database has the right entries
+	rows, err = database.Query("SELECT * FROM ReferenceObjectOneToMany")
 	assert.Nil(err)
 	defer rows.Close()
 
@@ -358,8 +561,8 @@ func TestUpdateWithForeignRelation(t *testing.T) {
 	assert.False(rows.Next())
 	assert.Nil(rows.Err())
 
-	// Test that the SQL ReferenceObjectTestObject database has been updated
-	rows, err = database.Query("SELECT * FROM ReferenceObjectTestObject")
+	// Test that the SQL ReferenceObjectOneToMany database has been updated
+	rows, err = database.Query("SELECT * FROM ReferenceObjectOneToMany")
 	assert.Nil(err)
 	defer rows.Close()
 
@@ -469,8 +672,8 @@ func TestDeleteWithForeignRelation(t *testing.T) {
 	assert.False(rows.Next())
 	assert.Nil(rows.Err())
 
-	// Test the SQL ReferenceObjectTestObject table to make sure there are no entries
-	rows, err = database.Query("SELECT * FROM ReferenceObjectTestObject")
+	// Test the SQL ReferenceObjectOneToMany table to make sure there are no entries
+	rows, err = database.Query("SELECT * FROM ReferenceObjectOneToMany")
 	assert.Nil(err)
 	defer rows.Close()
 
@@ -540,8 +743,8 @@ func TestReadWithForeignRelation(t *testing.T) {
 	assert.False(rows.Next())
 	assert.Nil(rows.Err())
 
-	// Test that the SQL ReferenceObjectTestObject database has the right entries
-	rows, err = database.Query("SELECT * FROM ReferenceObjectTestObject")
+	// Test that the SQL ReferenceObjectOneToMany database has the right entries
+	rows, err = database.Query("SELECT * FROM ReferenceObjectOneToMany")
 	assert.Nil(err)
 	defer rows.Close()
 
@@ -635,8 +838,8 @@ func TestSaveWithForeignRelation(t *testing.T) {
 	assert.False(rows.Next())
 	assert.Nil(rows.Err())
 
-	// Test that the SQL ReferenceObjectTestObject database has the right entries
-	rows, err = database.Query("SELECT * FROM ReferenceObjectTestObject")
+	// Test that the SQL ReferenceObjectOneToMany database has the right entries
+	rows, err = database.Query("SELECT * FROM ReferenceObjectOneToMany")
 	assert.Nil(err)
 	defer rows.Close()
 
@@ -703,8 +906,8 @@ func TestSaveWithForeignRelation(t *testing.T) {
 	assert.False(rows.Next())
 	assert.Nil(rows.Err())
 
-	// Test that the SQL ReferenceObjectTestObject database has been updated
-	rows, err = database.Query("SELECT * FROM ReferenceObjectTestObject")
+	// Test that the SQL ReferenceObjectOneToMany database has been updated
+	rows, err = database.Query("SELECT * FROM ReferenceObjectOneToMany")
 	assert.Nil(err)
 	defer rows.Close()
 
@@ -739,31 +942,308 @@ func TestSaveWithForeignRelation(t *testing.T) {
 	assert.Equal(ref.OneToMany[0], refObjs[refID].OneToMany[0])
 }
 
-// ---------- Test Setup ----------
+func TestDeleteWithSetNullAction(t *testing.T) {
+	actionSetup()
+	assert := assert.New(t)
 
-func referenceSetup() {
-	// Begin a transaction
-	tx, err := database.Begin()
-	if err != nil {
-		log.Fatal(err)
+	obj := TestObject{Name: "Jack", Age: 20, Weather: Summer}
+	action := ActionObject{Nullable: &obj}
+
+	// Insert the objects
+	_, err := wrapper.Insert(&obj)
+	assert.Nil(err)
+
+	actionID, err := actionWrapper.Insert(&action)
+	assert.Nil(err)
+
+	// Delete the referenced object
+	assert.Nil(wrapper.Delete(&obj))
+
+	// The referencing object should still be present with a nil reference
+	actions, err := actionWrapper.Get()
+	assert.Nil(err)
+	assert.Equal(1, len(actions))
+	assert.Nil(actions[actionID].Nullable)
+
+	// The referencing row should have a null column
+	var nullableID sql.NullInt64
+	assert.Nil(database.QueryRow("SELECT NullableID FROM ActionObject WHERE id = ?", actionID).Scan(&nullableID))
+	assert.False(nullableID.Valid)
+
+	// The cleared reference matches the database, so it is not pending
+	changes, err := actionWrapper.Changes(&action)
+	assert.Nil(err)
+	assert.Empty(changes)
+}
+
+func TestMigrateForeignKeyActions(t *testing.T) {
+	actionSetup()
+	assert := assert.New(t)
+
+	// Recreate the table with the actions it had before the tags were added
+	_, err := database.Exec("DROP TABLE ActionObject")
+	assert.Nil(err)
+	_, err = database.Exec("CREATE TABLE ActionObject(id INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY, NullableID INT UNSIGNED, FOREIGN KEY (NullableID) REFERENCES TestObject(id) ON DELETE CASCADE ON UPDATE CASCADE, RestrictedID INT UNSIGNED, FOREIGN KEY (RestrictedID) REFERENCES TestObject(id) ON DELETE CASCADE ON UPDATE CASCADE)")
+	assert.Nil(err)
+
+	// Creating a new wrapper should replace the foreign keys with the actions in the tags
+	actionWrapper, err = sql_wrapper.NewWrapper[*ActionObject](database, ActionObject{})
+	assert.Nil(err)
+
+	rows, err := database.Query("SELECT k.COLUMN_NAME, r.DELETE_RULE FROM information_schema.KEY_COLUMN_USAGE k JOIN information_schema.REFERENTIAL_CONSTRAINTS r ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME WHERE k.TABLE_SCHEMA = DATABASE() AND k.TABLE_NAME = 'ActionObject' AND k.REFERENCED_TABLE_NAME IS NOT NULL ORDER BY k.COLUMN_NAME")
+	assert.Nil(err)
+	defer rows.Close()
+
+	var column, rule string
+	assert.True(rows.Next())
+	assert.Nil(rows.Scan(&column, &rule))
+	assert.Equal("NullableID", column)
+	assert.Equal("SET NULL", rule)
+
+	assert.True(rows.Next())
+	assert.Nil(rows.Scan(&column, &rule))
+	assert.Equal("RestrictedID", column)
+	assert.Equal("RESTRICT", rule)
+
+	assert.False(rows.Next())
+	assert.Nil(rows.Err())
+}
+
+func TestDeleteWithRestrictAction(t *testing.T) {
+	actionSetup()
+	assert := assert.New(t)
+
+	obj := TestObject{Name: "John", Age: 25, Weather: Spring}
+	action := ActionObject{Restricted: &obj}
+
+	// Insert the objects
+	objID, err := wrapper.Insert(&obj)
+	assert.Nil(err)
+
+	actionID, err := actionWrapper.Insert(&action)
+	assert.Nil(err)
+
+	// Deleting the referenced object should fail
+	assert.NotNil(wrapper.Delete(&obj))
+
+	// Both objects should be unchanged
+	objs, err := wrapper.Get()
+	assert.Nil(err)
+	assert.Equal(&obj, objs[objID])
+
+	actions, err := actionWrapper.Get()
+	assert.Nil(err)
+	assert.Equal(&obj, actions[actionID].Restricted)
+}
+
+func TestDeleteWithCascadeChain(t *testing.T) {
+	referenceSetup()
+	assert := assert.New(t)
+
+	var err error
+	chainWrapper, err = sql_wrapper.NewWrapper[*ChainObject](database, ChainObject{})
+	assert.Nil(err)
+
+	obj1 := TestObject{Name: "Jack", Age: 20, Weather: Summer}
+	obj2 := TestObject{Name: "John", Age: 25, Weather: Spring}
+	ref := ReferenceObject{OneToOne: &obj1, ManyToOne: &obj2, OneToMany: []*TestObject{&obj2}}
+	chain := ChainObject{Reference: &ref}
+
+	// Insert the objects
+	_, err = wrapper.Insert(&obj1)
+	assert.Nil(err)
+
+	obj2ID, err := wrapper.Insert(&obj2)
+	assert.Nil(err)
+
+	_, err = referenceWrapper.Insert(&ref)
+	assert.Nil(err)
+
+	_, err = chainWrapper.Insert(&chain)
+	assert.Nil(err)
+
+	// Deleting the first object cascades to the reference object and then the chain object
+	assert.Nil(wrapper.Delete(&obj1))
+
+	// The cascaded objects should be removed from their wrappers
+	refObjs, err := referenceWrapper.Get()
+	assert.Nil(err)
+	assert.Equal(0, len(refObjs))
+
+	chainObjs, err := chainWrapper.Get()
+	assert.Nil(err)
+	assert.Equal(0, len(chainObjs))
+
+	// The second object should be unaffected
+	objs, err := wrapper.Get()
+	assert.Nil(err)
+	assert.Equal(1, len(objs))
+	assert.Equal(&obj2, objs[obj2ID])
+
+	// The database should match the wrappers
+	var count int
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM ReferenceObject").Scan(&count))
+	assert.Equal(0, count)
+
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM ReferenceObjectOneToMany").Scan(&count))
+	assert.Equal(0, count)
+
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM ChainObject").Scan(&count))
+	assert.Equal(0, count)
+}
+
+func TestSelfReferentialRelation(t *testing.T) {
+	selfSetup()
+	assert := assert.New(t)
+
+	root := Category{Name: "Root"}
+	child1 := Category{Name: "Child 1", Parent: &root}
+	child2 := Category{Name: "Child 2", Parent: &root}
+	grandchild := Category{Name: "Grandchild", Parent: &child1}
+
+	// Insert the tree
+	for _, category := range []*Category{&root, &child1, &child2, &grandchild} {
+		_, err := categoryWrapper.Insert(category)
+		assert.Nil(err)
 	}
 
-	// Drop the current wrapper
-	_, err = database.Exec("DROP TABLE IF EXISTS ReferenceObjectTestObject;")
-	if err != nil {
-		log.Fatal(err)
+	// The children should be set from the parents
+	assert.Equal([]*Category{&child1, &child2}, root.Children)
+	assert.Equal([]*Category{&grandchild}, child1.Children)
+	assert.Equal(0, len(child2.Children))
+
+	// Read the tree into a new wrapper
+	var err error
+	categoryWrapper, err = sql_wrapper.NewWrapper[*Category](database, Category{})
+	assert.Nil(err)
+	assert.Nil(categoryWrapper.Read())
+
+	categories, err := categoryWrapper.Get()
+	assert.Nil(err)
+	assert.Equal(4, len(categories))
+
+	for _, category := range categories {
+		if category.Name == "Root" {
+			assert.Nil(category.Parent)
+			assert.Equal(2, len(category.Children))
+		} else if category.Name == "Child 1" {
+			assert.Equal("Root", category.Parent.Name)
+			assert.Equal(1, len(category.Children))
+			assert.Equal("Grandchild", category.Children[0].Name)
+		}
 	}
 
-	_, err = database.Exec("DROP TABLE IF EXISTS ReferenceObject;")
-	if err != nil {
-		log.Fatal(err)
+	// Deleting a category should cascade to its descendants
+	for _, category := range categories {
+		if category.Name == "Child 1" {
+			assert.Nil(categoryWrapper.Delete(category))
+		}
 	}
 
-	_, err = database.Exec("DROP TABLE IF EXISTS TestObject;")
+	categories, err = categoryWrapper.Get()
+	assert.Nil(err)
+	assert.Equal(2, len(categories))
+}
+
+func TestMultipleRelationsToSameType(t *testing.T) {
+	selfSetup()
+	assert := assert.New(t)
+
+	jack := Member{Name: "Jack"}
+	john := Member{Name: "John"}
+	luke := Member{Name: "Luke"}
+
+	// Insert the members
+	jackID, err := memberWrapper.Insert(&jack)
+	assert.Nil(err)
+
+	johnID, err := memberWrapper.Insert(&john)
+	assert.Nil(err)
+
+	lukeID, err := memberWrapper.Insert(&luke)
+	assert.Nil(err)
+
+	// Link the members through both relations
+	jack.Followers = []*Member{&john, &luke}
+	jack.Following = []*Member{&luke}
+	assert.Nil(memberWrapper.Update(&jack))
+
+	// Each relation should be stored in its own table
+	var count int
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM MemberFollowers WHERE MemberID = ?", jackID).Scan(&count))
+	assert.Equal(2, count)
+
+	var followingID int
+	assert.Nil(database.QueryRow("SELECT FollowingID FROM MemberFollows WHERE MemberID = ?", jackID).Scan(&followingID))
+	assert.Equal(lukeID, followingID)
+
+	// Read the members into a new wrapper
+	memberWrapper, err = sql_wrapper.NewWrapper[*Member](database, Member{})
+	assert.Nil(err)
+	assert.Nil(memberWrapper.Read())
+
+	readJack, err := memberWrapper.GetByID(jackID)
+	assert.Nil(err)
+
+	readJohn, err := memberWrapper.GetByID(johnID)
+	assert.Nil(err)
+
+	readLuke, err := memberWrapper.GetByID(lukeID)
+	assert.Nil(err)
+
+	assert.ElementsMatch([]*Member{readJohn, readLuke}, readJack.Followers)
+	assert.Equal([]*Member{readLuke}, readJack.Following)
+}
+
+func TestLegacyJunctionName(t *testing.T) {
+	dropTables()
+	assert := assert.New(t)
+
+	var err error
+	bookWrapper, err = sql_wrapper.NewWrapper[*Book](database, Book{})
+	assert.Nil(err)
+
+	book := Book{Title: "First"}
+	bookID, err := bookWrapper.Insert(&book)
+	assert.Nil(err)
+
+	// Store a link in the table named by the source and target structs
+	_, err = database.Exec("CREATE TABLE AuthorBook(AuthorID INT UNSIGNED, BookID INT UNSIGNED UNIQUE);")
+	assert.Nil(err)
+
+	_, err = database.Exec("INSERT INTO AuthorBook (AuthorID, BookID) VALUES (1, ?);", bookID)
+	assert.Nil(err)
+
+	// Creating the wrapper should rename the legacy table
+	authorWrapper, err = sql_wrapper.NewWrapper[*Author](database, Author{})
+	assert.Nil(err)
+
+	var count int
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'AuthorBook'").Scan(&count))
+	assert.Equal(0, count)
+
+	// The link should be read from the renamed table
+	_, err = database.Exec("INSERT INTO Author (id, Name) VALUES (1, 'Jack');")
+	assert.Nil(err)
+	assert.Nil(authorWrapper.Read())
+
+	author, err := authorWrapper.GetByID(1)
+	assert.Nil(err)
+	assert.Equal([]*Book{&book}, author.Books)
+}
+
+// ---------- Test Setup ----------
+
+func referenceSetup() {
+	// Begin a transaction
+	tx, err := database.Begin()
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// Drop the current wrapper
+	dropTables()
+
 	// Rollback the transcation on a panic
 	defer func() {
 		if err != nil {
@@ -786,3 +1266,37 @@ func referenceSetup() {
 		log.Fatal(err)
 	}
 }
+
+func actionSetup() {
+	// Drop the current wrapper
+	dropTables()
+
+	// Create the new wrappers
+	var err error
+	wrapper, err = sql_wrapper.NewWrapper[*TestObject](database, TestObject{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	actionWrapper, err = sql_wrapper.NewWrapper[*ActionObject](database, ActionObject{})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func selfSetup() {
+	// Drop the current wrapper
+	dropTables()
+
+	// Create the new wrappers
+	var err error
+	categoryWrapper, err = sql_wrapper.NewWrapper[*Category](database, Category{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	memberWrapper, err = sql_wrapper.NewWrapper[*Member](database, Member{})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -3,6 +3,7 @@ package sql_wrapper
 import (
 	"database/sql"
 	"fmt"
+	"reflect"
 )
 
 // Readable makes sure an object knows how to read itself in from SQL
@@ -11,15 +12,29 @@ type Readable interface {
 	Read(*sql.DB) (map[int]Readable, error)
 }
 
+// TxReadable is a Readable that can also read itself in through a transaction. Wrappers that run in a Tx read through
+// ReadTx, so the rows they read include the uncommitted changes of the transaction
+type TxReadable interface {
+	Readable
+	ReadTx(Querier) (map[int]Readable, error)
+}
+
 // Schema represents the build surrounding a table in SQL
 type schema struct {
 	template Readable                    // The golang object to represent
 	objects  map[int]identifiableWrapper // Objects saved into the table
+	ids      map[interface{}]int         // The ID of every object in the table, keyed by the object
 	db       *sql.DB                     // SQL Database that holds storage for the library
 
-	table  string   // The table name
-	cols   []string // Column names
-	nextID int      // The next ID to set an object to
+	table   string   // The table name
+	cols    []string // Column names
+	indexes []Index  // Indexes and unique constraints on the table
+	nextID  int      // The next ID to set an object to
+	loaded  bool     // Whether every row of the table was read into the schema
+
+	plan   []fieldPlan       // The metadata of every struct field
+	batch  BatchConfig       // How batch operations group rows into statements
+	states map[int]persisted // The state of every object as last written to or read from the database
 }
 
 // name returns the name of the table the schema represents
@@ -28,17 +43,22 @@ func (s *schema) name() string {
 }
 
 // save makes sure an object is registered to the schema and returns its ID
-func (s *schema) Save(val Readable) error {
+func (s *schema) Save(tx *Tx, val Readable) error {
+	// Objects with relation fields that cascade saves are saved together with the objects they reference
+	if s.cascades() {
+		return s.saveCascade(tx, val)
+	}
+
 	_, err := s.validate(val)
 	if err != nil {
 		// If there is an error, then the object is not present and needs to be inserted
 		// Object is not present, so insert it
-		_, err := s.insert(val)
+		_, err := s.insert(tx, val)
 		return err
 	}
 
 	// Otherwise, update the object
-	return s.update(val)
+	return s.update(tx, val)
 }
 
 // get gets the objects currently loaded
@@ -71,34 +91,41 @@ func (s *schema) getID(val Readable) (int, error) {
 func (s *schema) getByID(id int) (Readable, error) {
 	obj, ok := s.objects[id]
 	if !ok {
-		return nil, fmt.Errorf("no object with id in schema")
+		return nil, &ObjectNotFoundError{Schema: s.table, ID: id}
 	}
 
 	return obj.Object(), nil
 }
 
 // insert inserts a new entry and returns the ID of the new entry
-func (s *schema) insert(val Readable) (int, error) {
+func (s *schema) insert(tx *Tx, val Readable) (int, error) {
+	// Objects are identified by their pointer afterwards
+	if _, err := identityKey(val); err != nil {
+		return -1, err
+	}
+
 	// Start a transaction in the database
-	tx, err := s.db.Begin()
+	t, err := s.begin(tx)
 	if err != nil {
 		return -1, err
 	}
 
-	// Rollback the transaction if there is an error
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
-
 	// Add the object to the internal map
 	id := s.nextID
+	tx.touch(s, id)
 	s.nextID++
 
-	obj := newIdentifiableWrapper(s, val, id)
+	s.add(id, val)
 
-	s.objects[id] = obj
+	// Rollback the transaction, remove the object and restore the owners if there is an error
+	var owners []saveStep
+	defer func() {
+		if err != nil {
+			t.Rollback()
+			s.remove(id)
+			restoreSteps(owners)
+		}
+	}()
 
 	// Add the object to SQL
 	strs, err := s.insertSQL(id, val)
@@ -106,18 +133,35 @@ func (s *schema) insert(val Readable) (int, error) {
 		return id, err
 	}
 
+	// Save owners that reference the object through inverse fields
+	var inverseStrs []statement
+	inverseStrs, owners, err = s.pushInversesSQL(tx, t, val)
+	if err != nil {
+		return id, err
+	}
+	strs = append(strs, inverseStrs...)
+
 	for _, str := range strs {
-		_, err = tx.Exec(str)
+		_, err = t.Exec(str.query, str.args...)
 		if err != nil {
 			return id, err
 		}
 	}
 
-	return id, tx.Commit()
+	if err = t.Commit(); err != nil {
+		return id, err
+	}
+	s.persist(id)
+	persistSteps(owners)
+
+	// Update inverse fields that mirror the schema
+	manager.syncInverses(s, val)
+	manager.syncSteps(owners)
+	return id, nil
 }
 
-// update updates an entry and returns the old object
-func (s *schema) update(val Readable) error {
+// update updates an entry, only writing the fields that changed
+func (s *schema) update(tx *Tx, val Readable) error {
 	obj, err := s.validate(val)
 	if err != nil {
 		return err
@@ -127,8 +171,90 @@ func (s *schema) update(val Readable) error {
 		return fmt.Errorf("object does not have valid id")
 	}
 
-	// Start a transaction in the database
-	tx, err := s.db.Begin()
+	// Start a transaction in the database, which the links are read in
+	t, err := s.begin(tx)
+	if err != nil {
+		return err
+	}
+
+	// Rollback the transaction and restore the owners if there is an error
+	var owners []saveStep
+	defer func() {
+		if err != nil {
+			t.Rollback()
+			restoreSteps(owners)
+		}
+	}()
+
+	// Only write the fields that changed
+	tx.touch(s, obj.GetID())
+	strs, err := s.updateSQL(t, obj.GetID(), obj.Object())
+	if err != nil {
+		return err
+	}
+
+	// Save owners that reference the object through inverse fields
+	var inverseStrs []statement
+	inverseStrs, owners, err = s.pushInversesSQL(tx, t, obj.Object())
+	if err != nil {
+		return err
+	}
+	strs = append(strs, inverseStrs...)
+
+	// Skip the write if nothing changed
+	if len(strs) == 0 {
+		return t.Commit()
+	}
+
+	for _, str := range strs {
+		_, err = t.Exec(str.query, str.args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = t.Commit(); err != nil {
+		return err
+	}
+	s.persist(obj.GetID())
+	persistSteps(owners)
+
+	// Update inverse fields that mirror the schema
+	manager.syncInverses(s, obj.Object())
+	manager.syncSteps(owners)
+	return nil
+}
+
+// updateFields updates the given fields of an entry, leaving the other fields pending
+func (s *schema) updateFields(tx *Tx, val Readable, names []string) error {
+	obj, err := s.validate(val)
+	if err != nil {
+		return err
+	}
+
+	if obj.GetID() < 0 {
+		return fmt.Errorf("object does not have valid id")
+	} else if len(names) == 0 {
+		return fmt.Errorf("no fields given")
+	}
+
+	// Get the fields to write
+	plans := []fieldPlan{}
+	seen := map[int]bool{}
+	for _, name := range names {
+		p, err := s.storedPlan(name)
+		if err != nil {
+			return err
+		}
+
+		if !seen[p.index] {
+			seen[p.index] = true
+			plans = append(plans, p)
+		}
+	}
+
+	// Start a transaction in the database, which the links are read in
+	t, err := s.begin(tx)
 	if err != nil {
 		return err
 	}
@@ -136,28 +262,40 @@ func (s *schema) update(val Readable) error {
 	// Rollback the transaction if there is an error
 	defer func() {
 		if err != nil {
-			tx.Rollback()
+			t.Rollback()
 		}
 	}()
 
-	// Add the object to SQL
-	strs, err := s.updateSQL(obj.GetID(), obj.Object())
+	strs, err := s.updateFieldsSQL(t, obj.GetID(), reflect.ValueOf(obj.Object()).Elem(), plans)
 	if err != nil {
 		return err
+	} else if len(strs) == 0 {
+		return t.Commit()
 	}
 
 	for _, str := range strs {
-		_, err = tx.Exec(str)
+		_, err = t.Exec(str.query, str.args...)
 		if err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit()
+	if err = t.Commit(); err != nil {
+		return err
+	}
+
+	tx.touch(s, obj.GetID())
+	for _, p := range plans {
+		s.persistField(obj.GetID(), p.index)
+	}
+
+	// Update inverse fields that mirror the schema
+	manager.syncInverses(s, obj.Object())
+	return nil
 }
 
 // delete deletes an entry
-func (s *schema) delete(val Readable) error {
+func (s *schema) delete(tx *Tx, val Readable) error {
 	obj, err := s.validate(val)
 	if err != nil {
 		return err
@@ -168,7 +306,7 @@ func (s *schema) delete(val Readable) error {
 	}
 
 	// Start a transaction in the database
-	tx, err := s.db.Begin()
+	t, err := s.begin(tx)
 	if err != nil {
 		return err
 	}
@@ -176,10 +314,7 @@ func (s *schema) delete(val Readable) error {
 	// Rollback the transaction if there is an error
 	defer func() {
 		if err != nil {
-			tx.Rollback()
-		} else {
-			// Remove the object to the internal map
-			delete(s.objects, obj.GetID())
+			t.Rollback()
 		}
 	}()
 
@@ -190,27 +325,83 @@ func (s *schema) delete(val Readable) error {
 	}
 
 	for _, str := range strs {
-		_, err = tx.Exec(str)
+		_, err = t.Exec(str.query, str.args...)
 		if err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit()
+	if err = t.Commit(); err != nil {
+		return err
+	}
+
+	// Remove the object from the internal map
+	tx.touch(s, obj.GetID())
+	s.remove(obj.GetID())
+
+	// Update objects that referenced the deleted object
+	manager.syncInverses(s, obj.Object())
+	manager.propagateDelete(tx, s, obj.Object(), obj.GetID())
+	return nil
 }
 
 // read reads an existing SQL table to populate the schema
-func (s *schema) read() error {
+func (s *schema) read(tx *Tx) error {
+	if err := s.load(tx); err != nil {
+		return err
+	}
+
+	// Set the IDs of lazy relations
+	if err := s.fillLazy(tx); err != nil {
+		return err
+	}
+	s.loaded = true
+
+	// Restore the order of ordered relations
+	if err := s.orderLinks(tx); err != nil {
+		return err
+	}
+	tx.touchAll(s)
+	s.persistAll()
+
+	// Update inverse fields that mirror the schema
+	return manager.refreshInverses(s)
+}
+
+// readItems reads the entries of the table with the template. Templates that implement TxReadable read through the
+// transaction, while other templates read from the database
+func (s *schema) readItems(tx *Tx) (map[int]Readable, error) {
+	if template, ok := s.template.(TxReadable); ok {
+		return template.ReadTx(s.conn(tx))
+	}
+	return s.template.Read(s.db)
+}
+
+// load adds the entries returned by the template's Read method to the schema
+func (s *schema) load(tx *Tx) error {
 	// Add the entries to the schema
-	items, err := s.template.Read(s.db)
+	if err := s.checkTx(tx); err != nil {
+		return err
+	}
+
+	items, err := s.readItems(tx)
 	if err != nil {
 		return err
 	}
 
+	// Objects are identified by their pointer, so every item must be one
+	for _, val := range items {
+		if _, err := identityKey(val); err != nil {
+			return err
+		}
+	}
+
 	// Loop through items and add them to the schema
+	tx.touch(s)
 	s.nextID = 0
 	for id, val := range items {
-		s.objects[id] = newIdentifiableWrapper(s, val, id)
+		tx.touch(s, id)
+		s.add(id, val)
 
 		if id > s.nextID {
 			s.nextID = id
@@ -224,18 +415,59 @@ func (s *schema) read() error {
 
 // validate is a helper method to validate that an object is a part of the schema
 func (s *schema) validate(val Readable) (identifiableWrapper, error) {
-	for _, v := range s.objects {
-		if val == v.Object() {
-			return v, nil
-		}
+	key, err := identityKey(val)
+	if err != nil {
+		return identifiableWrapper{}, err
+	}
+
+	if id, ok := s.ids[key]; ok {
+		return s.objects[id], nil
 	}
 	return identifiableWrapper{}, fmt.Errorf("object is not in schema")
 }
 
+// identityKey is a helper method that gets the key of an object in the ID index of a schema. Objects are keyed by
+// their address, so only non-nil pointers can be identified. Value types are not supported, since two copies of a value
+// with the same contents cannot be told apart
+func identityKey(val Readable) (interface{}, error) {
+	if val == nil || reflect.TypeOf(val).Kind() != reflect.Pointer || reflect.ValueOf(val).IsNil() {
+		return nil, fmt.Errorf("object of type %T is not a pointer and cannot be identified", val)
+	}
+	return val, nil
+}
+
+// add is a helper method that adds an object to the schema with the given ID
+func (s *schema) add(id int, val Readable) identifiableWrapper {
+	s.remove(id)
+
+	obj := newIdentifiableWrapper(s, val, id)
+	s.objects[id] = obj
+
+	if key, err := identityKey(val); err == nil {
+		s.ids[key] = id
+	}
+	return obj
+}
+
+// remove is a helper method that removes the object with the given ID from the schema
+func (s *schema) remove(id int) {
+	obj, ok := s.objects[id]
+	if !ok {
+		return
+	}
+
+	if key, err := identityKey(obj.Object()); err == nil && s.ids[key] == id {
+		delete(s.ids, key)
+	}
+	delete(s.objects, id)
+	delete(s.states, id)
+}
+
 // newSchema creates a new Schema
 func newSchema(db *sql.DB, template Readable) (*schema, error) {
 	s := &schema{db: db, template: template}
 	s.objects = make(map[int]identifiableWrapper)
+	s.ids = make(map[interface{}]int)
 	s.nextID = 1
 
 	// Start a transaction in the database
@@ -251,12 +483,23 @@ func newSchema(db *sql.DB, template Readable) (*schema, error) {
 		}
 	}()
 
-	// Create the SQL table this schema needs
-	strs, err := s.createTableSQL()
+	// Cache the metadata of the fields
+	if err = s.compile(); err != nil {
+		return s, err
+	}
+
+	// Rename tables of slice relations that still have their legacy name, then create the SQL tables this schema needs
+	strs, err := s.renameJunctionsSQL(tx)
 	if err != nil {
 		return s, err
 	}
 
+	created, err := s.createTableSQL()
+	if err != nil {
+		return s, err
+	}
+	strs = append(strs, created...)
+
 	for _, str := range strs {
 		_, err = tx.Exec(str)
 		if err != nil {
@@ -264,8 +507,36 @@ func newSchema(db *sql.DB, template Readable) (*schema, error) {
 		}
 	}
 
+	// Add or change indexes that differ from the ones on an existing table
+	existing, err := s.readIndexes(tx)
+	if err != nil {
+		return s, err
+	}
+
+	for _, str := range s.migrateIndexesSQL(existing) {
+		_, err = tx.Exec(str)
+		if err != nil {
+			return s, err
+		}
+	}
+
+	// Replace foreign keys whose actions differ from the tags on an existing table
+	keys, err := s.migrateForeignKeysSQL(tx)
+	if err != nil {
+		return s, err
+	}
+
+	for _, str := range keys {
+		_, err = tx.Exec(str)
+		if err != nil {
+			return s, err
+		}
+	}
+
 	// Add the schema to the manager
-	manager.addSchema(s)
+	if err = manager.addSchema(s); err != nil {
+		return s, err
+	}
 
 	return s, tx.Commit()
 }
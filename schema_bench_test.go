@@ -0,0 +1,303 @@
+package sql_wrapper
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// benchRows is the number of objects in the schemas used by benchmarks
+const benchRows = 100000
+
+// benchObject is used to benchmark schemas without a database
+type benchObject struct {
+	Name string `sql:"Name" def:"VARCHAR(128)"`
+}
+
+// Read is not used by the benchmarks
+func (b benchObject) Read(db *sql.DB) (map[int]Readable, error) {
+	return map[int]Readable{}, nil
+}
+
+// benchSchema creates a schema holding benchRows objects
+func benchSchema() (*schema, []*benchObject) {
+	s := &schema{template: benchObject{}, table: "benchObject"}
+	s.objects = make(map[int]identifiableWrapper)
+	s.ids = make(map[interface{}]int)
+
+	objs := make([]*benchObject, benchRows)
+	for i := range objs {
+		objs[i] = &benchObject{Name: "Name"}
+		s.add(i+1, objs[i])
+	}
+	s.nextID = benchRows + 1
+
+	return s, objs
+}
+
+// BenchmarkGetID looks up the IDs of objects through the ID index
+func BenchmarkGetID(b *testing.B) {
+	s, objs := benchSchema()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := s.getID(objs[i%len(objs)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGetIDScan looks up the IDs of objects by scanning every object, which is how lookups worked before the
+// ID index
+func BenchmarkGetIDScan(b *testing.B) {
+	s, objs := benchSchema()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		val := Readable(objs[i%len(objs)])
+
+		found := false
+		for _, v := range s.objects {
+			if val == v.Object() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			b.Fatal("object is not in schema")
+		}
+	}
+}
+
+// benchOwner is used to benchmark relations without a database
+type benchOwner struct {
+	Name    string         `sql:"Name" def:"VARCHAR(128)"`
+	Objects []*benchObject `sql:"ObjectID" rel:"one-to-many"`
+}
+
+// Read is not used by the benchmarks
+func (b benchOwner) Read(db *sql.DB) (map[int]Readable, error) {
+	return map[int]Readable{}, nil
+}
+
+// BenchmarkLinks creates the links of a slice relation with benchRows elements, which resolves the ID of every element
+func BenchmarkLinks(b *testing.B) {
+	s, objs := benchSchema()
+
+	// Register the schema of the elements
+	schemas := manager.schemas
+	manager.schemas = map[string]*schema{s.name(): s}
+	defer func() { manager.schemas = schemas }()
+
+	owner, err := newBenchSchema(benchOwner{})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	field := reflect.TypeOf(owner.template).Field(1)
+	slice := reflect.ValueOf(objs)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := owner.links(field, slice, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchRecord is used to benchmark SQL generation without a database
+type benchRecord struct {
+	Name    string         `sql:"Name" def:"VARCHAR(128)"`
+	Age     int            `sql:"Age" def:"INT"`
+	Email   string         `sql:"Email" def:"VARCHAR(128)"`
+	Hidden  string         `sql:"-"`
+	Owner   *benchObject   `sql:"OwnerID" rel:"many-to-one"`
+	Objects []*benchObject `sql:"ObjectID" rel:"many-to-many"`
+}
+
+// Read is not used by the benchmarks
+func (b benchRecord) Read(db *sql.DB) (map[int]Readable, error) {
+	return map[int]Readable{}, nil
+}
+
+// BenchmarkInsertSQL creates the statements that insert records with scalar, pointer and slice fields
+func BenchmarkInsertSQL(b *testing.B) {
+	s, objs := benchSchema()
+
+	// Register the schema of the targets
+	schemas := manager.schemas
+	manager.schemas = map[string]*schema{s.name(): s}
+	defer func() { manager.schemas = schemas }()
+
+	records, err := newBenchSchema(benchRecord{})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	record := &benchRecord{Name: "Jack", Age: 19, Email: "jack@example.com", Owner: objs[0], Objects: objs[:3]}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := records.insertSQL(i+1, record); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkInsertSQLReflect creates the same statements as BenchmarkInsertSQL by reading the tags of every field on
+// every call, which is how statements were created before the field plan
+func BenchmarkInsertSQLReflect(b *testing.B) {
+	s, objs := benchSchema()
+
+	// Register the schema of the targets
+	schemas := manager.schemas
+	manager.schemas = map[string]*schema{s.name(): s}
+	defer func() { manager.schemas = schemas }()
+
+	records, err := newBenchSchema(benchRecord{})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	record := &benchRecord{Name: "Jack", Age: 19, Email: "jack@example.com", Owner: objs[0], Objects: objs[:3]}
+
+	// Make sure both benchmarks create the same statements
+	want, err := records.insertSQL(1, record)
+	if err != nil {
+		b.Fatal(err)
+	}
+	got, err := reflectInsertSQL(records, 1, record)
+	if err != nil {
+		b.Fatal(err)
+	} else if !reflect.DeepEqual(got, want) {
+		b.Fatalf("statements %v do not match %v", got, want)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := reflectInsertSQL(records, i+1, record); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// reflectInsertSQL creates the statements that insert an object without the field plan of its schema, reading the
+// tags of every field instead
+func reflectInsertSQL(s *schema, id int, obj Readable) ([]statement, error) {
+	columns := []string{"id"}
+	values := []interface{}{id}
+	links := []statement{}
+
+	t := reflect.TypeOf(s.template)
+	v := reflect.ValueOf(obj).Elem()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, err := getName(field)
+		if err != nil {
+			return nil, err
+		} else if _, ok := getInverse(field); name == "-" || ok {
+			continue
+		}
+
+		rel := getRelation(field)
+		if rel == UndefinedRelationType {
+			columns = append(columns, name)
+			values = append(values, v.Field(i).Interface())
+			continue
+		}
+
+		target, err := manager.getSchema(getTarget(field))
+		if err != nil {
+			return nil, err
+		}
+
+		if rel == OneToOne || rel == ManyToOne {
+			var value interface{}
+			if !v.Field(i).IsNil() {
+				targetID, err := target.getID(v.Field(i).Interface().(Readable))
+				if err != nil {
+					return nil, err
+				}
+				value = targetID
+			}
+
+			columns = append(columns, name)
+			values = append(values, value)
+			continue
+		}
+
+		// Slice relations insert their links into another table
+		column, err := getLinkColumn(field)
+		if err != nil {
+			return nil, err
+		}
+
+		rows := []string{}
+		for k := 0; k < v.Field(i).Len(); k++ {
+			linked, err := getLinkTarget(field, v.Field(i).Index(k))
+			if err != nil {
+				return nil, err
+			}
+
+			targetID, err := target.getID(linked)
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, fmt.Sprintf("(%v, %v)", targetID, id))
+		}
+
+		if len(rows) > 0 {
+			links = append(links, statement{query: fmt.Sprintf("INSERT INTO %v(%v, %vID) VALUES %v;", getJunction(s.table, field), column, s.table, strings.Join(rows, ", ")), args: []interface{}{}})
+		}
+	}
+
+	statements := []statement{{query: fmt.Sprintf("INSERT INTO %v (%v) VALUES %v;", s.table, strings.Join(columns, ", "), placeholders(len(values))), args: values}}
+	return append(statements, links...), nil
+}
+
+// BenchmarkInsertManySQL creates the statements that insert a batch of 1000 records with scalar, pointer and slice fields
+func BenchmarkInsertManySQL(b *testing.B) {
+	s, objs := benchSchema()
+
+	// Register the schema of the targets
+	schemas := manager.schemas
+	manager.schemas = map[string]*schema{s.name(): s}
+	defer func() { manager.schemas = schemas }()
+
+	records, err := newBenchSchema(benchRecord{})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	ids := make([]int, 1000)
+	vals := make([]Readable, len(ids))
+	for i := range vals {
+		ids[i] = i + 1
+		vals[i] = &benchRecord{Name: "Jack", Age: 19, Email: "jack@example.com", Owner: objs[i], Objects: objs[i : i+3]}
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := records.insertManySQL(ids, vals); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// newBenchSchema creates a schema without a database by generating its create statements
+func newBenchSchema(template Readable) (*schema, error) {
+	s := &schema{template: template}
+	s.objects = make(map[int]identifiableWrapper)
+	s.ids = make(map[interface{}]int)
+
+	if err := s.compile(); err != nil {
+		return s, err
+	}
+
+	_, err := s.createTableSQL()
+	return s, err
+}
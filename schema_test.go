@@ -2,6 +2,7 @@ package sql_wrapper_test
 
 import (
 	"database/sql"
+	"flag"
 	"log"
 	"testing"
 
@@ -34,6 +35,11 @@ type TestObject struct {
 
 // Read reads in TestObjects from an SQL query
 func (t TestObject) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
+	return t.ReadTx(db)
+}
+
+// ReadTx reads in TestObjects through a transaction
+func (t TestObject) ReadTx(db sql_wrapper.Querier) (map[int]sql_wrapper.Readable, error) {
 	items := map[int]sql_wrapper.Readable{}
 
 	// Get the main elements
@@ -67,12 +73,47 @@ func (t TestObject) Read(db *sql.DB) (map[int]sql_wrapper.Readable, error) {
 var database *sql.DB
 var wrapper *sql_wrapper.Wrapper[*TestObject]
 
+// tables lists every table created by the tests, with referencing tables before the tables they reference
+var tables = []string{
+	"ActionObject",
+	"ChainObject",
+	"ReferenceObjectOneToMany",
+	"ReferenceObject",
+	"TestObject",
+	"AuthorBook",
+	"AuthorBooks",
+	"Author",
+	"Book",
+	"Category",
+	"MemberFollowers",
+	"MemberFollows",
+	"Member",
+	"Membership",
+	"Person",
+	"Club",
+	"PlaylistSongs",
+	"Playlist",
+	"Song",
+	"Player",
+	"Team",
+	"GarageCars",
+	"Garage",
+	"Car",
+	"IssueArticles",
+	"Issue",
+	"Article",
+	"Writer",
+}
+
 var cfg = mysql.Config{
 	User:   "sql_wrapper_test",
 	Passwd: "abc123",
 	Net:    "tcp",
 	Addr:   "127.0.0.1:3306",
 	DBName: "sql_wrapper_test",
+
+	// Read DATE and DATETIME columns into time.Time
+	ParseTime: true,
 }
 
 // ---------- Tests ----------
@@ -83,6 +124,12 @@ func TestInsert(t *testing.T) {
 
 	obj := TestObject{Name: "Jack", Age: 20, Weather: Summer, Hidden: "abc"}
 
+	// Objects that are not pointers cannot be identified
+	values, err := sql_wrapper.NewWrapper[TestObject](database, TestObject{})
+	assert.Nil(err)
+	_, err = values.Insert(obj)
+	assert.NotNil(err)
+
 	// Insert the test object
 	objID, err := wrapper.Insert(&obj)
 	assert.Nil(err)
@@ -239,6 +286,40 @@ func TestUpdate(t *testing.T) {
 	assert.Equal(obj.Weather, objs[objID].Weather)
 }
 
+func TestUpdateFields(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	obj := TestObject{Name: "Jack", Age: 20, Weather: Summer}
+	id, err := wrapper.Insert(&obj)
+	assert.Nil(err)
+
+	// Only the given fields are written
+	obj.Name = "John"
+	obj.Age = 21
+	assert.Nil(wrapper.UpdateFields(&obj, "Age"))
+
+	var (
+		name string
+		age  int
+	)
+	assert.Nil(database.QueryRow("SELECT Name, Age FROM TestObject WHERE id = ?", id).Scan(&name, &age))
+	assert.Equal("Jack", name)
+	assert.Equal(21, age)
+
+	// The other fields stay changed in the wrapper
+	assert.Equal("John", obj.Name)
+	changes, err := wrapper.Changes(&obj)
+	assert.Nil(err)
+	assert.Equal(1, len(changes))
+	assert.Equal("Name", changes[0].Field)
+
+	// Fields must exist and be stored
+	assert.NotNil(wrapper.UpdateFields(&obj, "Missing"))
+	assert.NotNil(wrapper.UpdateFields(&obj, "Hidden"))
+	assert.NotNil(wrapper.UpdateFields(&obj))
+}
+
 func TestDelete(t *testing.T) {
 	setup()
 	assert := assert.New(t)
@@ -463,20 +544,7 @@ func setup() {
 	}
 
 	// Drop the current wrapper
-	_, err = database.Exec("DROP TABLE IF EXISTS ReferenceObjectTestObject;")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	_, err = database.Exec("DROP TABLE IF EXISTS ReferenceObject;")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	_, err = database.Exec("DROP TABLE IF EXISTS TestObject;")
-	if err != nil {
-		log.Fatal(err)
-	}
+	dropTables()
 
 	// Rollback the transcation on a panic
 	defer func() {
@@ -496,6 +564,14 @@ func setup() {
 	}
 }
 
+func dropTables() {
+	for _, table := range tables {
+		if _, err := database.Exec("DROP TABLE IF EXISTS " + table + ";"); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
 func TestMain(m *testing.M) {
 	// Connect to a testing database
 	db, err := sql.Open("mysql", cfg.FormatDSN())
@@ -505,6 +581,13 @@ func TestMain(m *testing.M) {
 	database = db
 	defer database.Close()
 
+	// The tests need the testing database, while the benchmarks do not, so only the benchmarks run without it
+	flag.Parse()
+	if err := database.Ping(); err != nil {
+		log.Printf("skipping tests since the testing database cannot be reached: %v", err)
+		flag.Set("test.run", "^$")
+	}
+
 	// Run the tests
 	m.Run()
 }
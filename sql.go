@@ -22,312 +22,204 @@ func (s *schema) selectSQL() (string, error) {
 }
 */
 
+// statement is an SQL statement together with the values bound to its placeholders
+type statement struct {
+	query string        // The SQL of the statement
+	args  []interface{} // The values of the placeholders in the query
+}
+
 // deleteSQL creates a string that will remove an object in the SQL table
-func (s *schema) deleteSQL(id int) ([]string, error) {
-	statements := []string{}
+func (s *schema) deleteSQL(id int) ([]statement, error) {
+	statements := []statement{}
 
 	if s.table == "" {
 		return statements, fmt.Errorf("cannot insert record with no table name")
 	}
 
 	// Add another statement if a one-to-many relationship is present
-	t := reflect.TypeOf(s.template)
-	for i := 0; i < t.NumField(); i++ {
-		rel := getRelation(t.Field(i))
-		if rel == OneToMany || rel == ManyToMany {
-			// Get the combined table name
-			tableRef := strings.Split(t.Field(i).Type.String(), ".")[1]
-			combinedTable := s.table + tableRef
-
-			statements = append(statements, fmt.Sprintf("DELETE FROM %v WHERE %vID = %v", combinedTable, s.table, id))
+	for _, p := range s.plan {
+		if p.list() {
+			statements = append(statements, statement{query: fmt.Sprintf("DELETE FROM %v WHERE %vID = %v;", p.junction, s.table, id)})
 		}
 	}
 
-	statements = append(statements, fmt.Sprintf("DELETE FROM %v WHERE id = %v;", s.table, id))
+	statements = append(statements, statement{query: fmt.Sprintf("DELETE FROM %v WHERE id = %v;", s.table, id)})
 	return statements, nil
 }
 
-// updateSQL creates a string that will update the object in the SQL table
-func (s *schema) updateSQL(id int, obj Readable) ([]string, error) {
-	statements := []string{}
+// updateSQL creates strings that will update the fields of the object that changed since it was last written to or
+// read from the database. Nothing is returned if no field changed
+func (s *schema) updateSQL(q Querier, id int, obj Readable) ([]statement, error) {
+	statements := []statement{}
 
 	if s.table == "" {
 		return statements, fmt.Errorf("cannot insert record with no table name")
 	}
 
-	header := fmt.Sprintf("UPDATE %v SET ", s.table)
-	footer := fmt.Sprintf(" WHERE id = %v;", id)
-	body := ""
-
-	// Generate the body to set columns to new values
-	t := reflect.TypeOf(s.template)
-	v := reflect.ValueOf(obj)
-	for i := 0; i < t.NumField(); i++ {
-		// Get the name of the field
-		name, err := getName(t.Field(i))
-		if err != nil {
-			// Stop on error
-			return statements, err
-		} else if name == "-" {
-			// Skip fields with names '-'
-			continue
-		}
+	// Find the fields that changed
+	v := reflect.ValueOf(obj).Elem()
+	state, err := s.state(v)
+	if err != nil {
+		return statements, err
+	}
 
-		// Determine if the field is a foreign relation
-		rel := getRelation(t.Field(i))
-		if rel == UndefinedRelationType {
-			// Attribute is not a foreign relation so add normally
-			val := v.Elem().Field(i).Interface()
+	// The state is kept until the statements are committed, since they can still fail
+	return s.updateFieldsSQL(q, id, v, s.dirty(id, state))
+}
 
-			body += fmt.Sprintf("%v = %#v, ", name, val)
-		} else if rel == OneToOne || rel == ManyToOne {
-			// In the case of OneToOne or ManyToOne relationships, update to the ID to the field
-			tableRef := t.Field(i).Type.Elem().Name()
+// updateFieldsSQL creates strings that will update the given fields of the object
+func (s *schema) updateFieldsSQL(q Querier, id int, v reflect.Value, plans []fieldPlan) ([]statement, error) {
+	statements := []statement{}
 
-			// Get the schema the object belongs to
-			schema, err := manager.getSchema(tableRef)
+	columns := []string{}
+	values := []interface{}{}
+	for _, p := range plans {
+		// Determine if the field is a foreign relation
+		if p.rel == UndefinedRelationType {
+			// Attributes store their value in a column
+			columns = append(columns, p.name+" = ?")
+			values = append(values, v.Field(p.index).Interface())
+		} else if p.pointer() {
+			// One-to-one or many-to-one relations store the ID of the referenced object in a column
+			val, err := p.referenceValue(v.Field(p.index))
 			if err != nil {
 				return statements, err
 			}
-
-			// Dereference the object that implements the Readable Interface
-			obj, ok := v.Elem().Field(i).Interface().(Readable)
-			if !ok {
-				return statements, fmt.Errorf("cannot cast schema object as Readable")
-			}
-
-			if obj != nil && !reflect.ValueOf(obj).IsNil() {
-				// Get the ID of the object and add it if the object is not nil
-				id, err := schema.getID(obj)
-				if err != nil {
-					return statements, err
-				}
-
-				body += fmt.Sprintf("%v = %#v, ", name, id)
-			} else {
-				// If the object is nil, insert null
-				body += fmt.Sprintf("%v = NULL, ", name)
-			}
-		} else if rel == OneToMany || rel == ManyToMany {
-			// In the case of a OneToMany or ManyToMany relationship, update entries to another table
-			tableRef := strings.Split(t.Field(i).Type.String(), ".")[1]
-			combinedTable := s.table + tableRef
-
-			// Delete entries that previously exist with this ID
-			statements = append(statements, fmt.Sprintf("DELETE FROM %v WHERE %vID = %v;", combinedTable, s.table, id))
-
-			// Get the list of objects
-			slice := v.Elem().Field(i)
-			if slice.Kind() != reflect.Slice {
-				return statements, fmt.Errorf("relationship does not have slice type")
-			}
-
-			// Get the schema
-			schema, err := manager.getSchema(tableRef)
+			columns = append(columns, p.name+" = ?")
+			values = append(values, val)
+		} else if p.list() {
+			// In the case of a OneToMany or ManyToMany relationship, update the entries that changed in another table
+			strs, err := s.updateLinksSQL(q, id, p.field, v.Field(p.index))
 			if err != nil {
 				return statements, err
 			}
-
-			for i := 0; i < slice.Len(); i++ {
-				val := slice.Index(i)
-
-				// Cast the val to a Readable object
-				readable, ok := val.Interface().(Readable)
-				if !ok {
-					return statements, fmt.Errorf("cannot cast element in relationship to Readable")
-				}
-
-				// Get the ID of the object
-				objID, err := schema.getID(readable)
-				if err != nil {
-					return statements, err
-				}
-
-				statements = append(statements, fmt.Sprintf("INSERT INTO %v(%v, %vID) VALUES (%v, %v);", combinedTable, name, s.table, objID, id))
-			}
+			statements = append(statements, strs...)
 		}
 	}
 
-	statements = append([]string{header + body[0:len(body)-2] + footer}, statements...)
+	if len(columns) > 0 {
+		statements = append([]statement{{query: fmt.Sprintf("UPDATE %v SET %v WHERE id = %v;", s.table, strings.Join(columns, ", "), id), args: values}}, statements...)
+	}
 
 	return statements, nil
 }
 
 // insertSQL creates a string that will insert the given object into an SQL table
-func (s *schema) insertSQL(id int, obj Readable) ([]string, error) {
-	statements := []string{}
+func (s *schema) insertSQL(id int, obj Readable) ([]statement, error) {
+	statements := []statement{}
 
 	// Make sure the table name is set
 	if s.table == "" {
 		return statements, fmt.Errorf("cannot insert record with no table name")
 	}
 
-	header := fmt.Sprintf("INSERT INTO %v (id, ", s.table)
-	body := fmt.Sprintf(") VALUES (%v, ", id)
-	footer := ");"
+	// Generate the values to insert
+	v := reflect.ValueOf(obj).Elem()
 
-	var columns []string
+	columns, values, err := s.rowSQL(id, v)
+	if err != nil {
+		return statements, err
+	}
+	statements = append(statements, statement{query: fmt.Sprintf("INSERT INTO %v (%v) VALUES %v;", s.table, strings.Join(columns, ", "), placeholders(len(values))), args: values})
 
-	// Generate the values to insert
-	t := reflect.TypeOf(s.template)
-	v := reflect.ValueOf(obj)
+	// In the case of a OneToMany or ManyToMany relationships, add entries to another table
+	for _, p := range s.plan {
+		if p.skip || !p.list() {
+			continue
+		}
 
-	// Create an insert string for normal attributes
-	for i := 0; i < t.NumField(); i++ {
-		// Get the name of the field
-		name, err := getName(t.Field(i))
+		strs, err := s.linksSQL(id, p.field, v.Field(p.index))
 		if err != nil {
-			// Stop on error
 			return statements, err
-		} else if name == "-" {
-			// Skip fields with names '-'
-			continue
 		}
+		statements = append(statements, strs...)
+	}
 
-		// Determine if the field is a foreign relation
-		rel := getRelation(t.Field(i))
-		if rel == UndefinedRelationType {
-			// Attribute is not a foreign relation so add normally
-			columns = append(columns, name)
-			body += fmt.Sprintf("%#v, ", v.Elem().Field(i).Interface())
-		} else if rel == OneToOne || rel == ManyToOne {
-			// Attribute is a one-to-one or many-to-one foreign relation
-			tableRef := t.Field(i).Type.Elem().Name()
-
-			// In the case of OneToOne or ManyToOne relationships, add the ID to the field
-			columns = append(columns, name)
+	return statements, nil
+}
 
-			// Get the schema the object belongs to
-			schema, err := manager.getSchema(tableRef)
-			if err != nil {
-				return statements, err
-			}
+// placeholders is a helper method that creates the placeholders of a row with the given number of values
+func placeholders(count int) string {
+	return "(" + strings.TrimSuffix(strings.Repeat("?, ", count), ", ") + ")"
+}
 
-			// Dereference the object that implements the Readable Interface
-			val, ok := v.Elem().Field(i).Interface().(Readable)
-			if !ok {
-				return statements, fmt.Errorf("cannot cast schema object as Readable")
-			}
+// rowSQL creates the columns of the row that stores an object in the table of the schema, together with the values
+// bound to them
+func (s *schema) rowSQL(id int, v reflect.Value) ([]string, []interface{}, error) {
+	columns := []string{"id"}
+	values := []interface{}{id}
 
-			if val != nil && !reflect.ValueOf(val).IsNil() {
-				// Get the ID of the object if it is not nil
-				id, err := schema.getID(val)
-				if err != nil {
-					return statements, err
-				}
-
-				body += fmt.Sprintf("%#v, ", id)
-			} else {
-				// If the object is nil, insert null
-				body += "NULL, "
-			}
-		} else if rel == OneToMany || rel == ManyToMany {
-			// In the case of a OneToMany or ManyToMany relationships, add entries to another table
-			tableRef := strings.Split(t.Field(i).Type.String(), ".")[1]
-			combinedTable := s.table + tableRef
-
-			// Get the list of objects
-			slice := v.Elem().Field(i)
-			if slice.Kind() != reflect.Slice {
-				return statements, fmt.Errorf("relationship does not have slice type")
-			}
+	for _, p := range s.plan {
+		if p.skip {
+			// Skip fields with names '-' and inverse fields
+			continue
+		}
 
-			// Get the schema
-			schema, err := manager.getSchema(tableRef)
+		// Determine if the field is a foreign relation
+		if p.rel == UndefinedRelationType {
+			// Attribute is not a foreign relation so add normally
+			columns = append(columns, p.name)
+			values = append(values, v.Field(p.index).Interface())
+		} else if p.pointer() {
+			// Attribute is a one-to-one or many-to-one foreign relation, so add the ID to the field
+			val, err := p.referenceValue(v.Field(p.index))
 			if err != nil {
-				return statements, err
+				return columns, values, err
 			}
 
-			for i := 0; i < slice.Len(); i++ {
-				val := slice.Index(i)
-
-				// Cast the val to a Readable object
-				readable, ok := val.Interface().(Readable)
-				if !ok {
-					return statements, fmt.Errorf("cannot cast element in relationship to Readable")
-				}
-
-				// Get the ID of the object
-				objID, err := schema.getID(readable)
-				if err != nil {
-					return statements, err
-				}
-
-				statements = append(statements, fmt.Sprintf("INSERT INTO %v(%v, %vID) VALUES (%v, %v);", combinedTable, name, s.table, objID, id))
-			}
+			columns = append(columns, p.name)
+			values = append(values, val)
 		}
 	}
 
-	statements = append(statements, header+strings.Join(columns, ", ")+body[0:len(body)-2]+footer)
-
-	temp := statements[0]
-	statements[0] = statements[len(statements)-1]
-	statements[len(statements)-1] = temp
-
-	return statements, nil
+	return columns, values, nil
 }
 
 // createTableSQL creates a string that will create an SQL table
 func (s *schema) createTableSQL() ([]string, error) {
 	statements := []string{}
 
-	s.table = reflect.TypeOf(s.template).Name()
-
 	// The header and footer for the create statement
-	header := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %v(id INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY, ", reflect.TypeOf(s.template).Name())
+	header := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %v(id INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY, ", s.table)
 	footer := ");"
 	body := ""
 
-	// Loop through struct tags
-	fields := reflect.VisibleFields(reflect.TypeOf(s.template))
-	for _, field := range fields {
-		// Get the name of the field
-		name, err := getName(field)
-		if err != nil {
-			// Stop on error
-			return statements, err
-		} else if name == "-" {
-			// Skip fields with names '-'
+	for _, p := range s.plan {
+		if p.skip {
+			// Skip fields with names '-' and inverse fields
 			continue
 		}
 
 		// Determine if the field is a foreign relation
-		rel := getRelation(field)
-		if rel == UndefinedRelationType {
+		switch p.rel {
+		case UndefinedRelationType:
 			// Field is not a foreign relation so add normally
-			s.cols = append(s.cols, name)
-
-			// Get the definition of the field
-			def, err := getDefinition(field)
-			if err != nil {
-				return statements, err
-			}
+			s.cols = append(s.cols, p.name)
+			body += fmt.Sprintf("%v %v, ", p.name, p.def)
 
-			// Add the name and definition to the SQL
-			body += fmt.Sprintf("%v %v, ", name, def)
-		} else if rel == OneToOne {
+		case OneToOne:
 			// The field has a one-to-one foreign relation
-			tableRef := field.Type.Elem().Name()
+			body += fmt.Sprintf("%[1]v INT UNSIGNED UNIQUE, FOREIGN KEY (%[1]v) REFERENCES %[2]v(id) ON DELETE %[3]v ON UPDATE %[4]v, ", p.name, p.target, p.onDelete, p.onUpdate)
 
-			body += fmt.Sprintf("%[1]v INT UNSIGNED UNIQUE, FOREIGN KEY (%[1]v) REFERENCES %[2]v(id) ON DELETE CASCADE ON UPDATE CASCADE, ", name, tableRef)
-		} else if rel == ManyToOne {
+		case ManyToOne:
 			// The field has a many-to-one foreign relation
-			tableRef := field.Type.Elem().Name()
-
-			body += fmt.Sprintf("%[1]v INT UNSIGNED, FOREIGN KEY (%[1]v) REFERENCES %[2]v(id) ON DELETE CASCADE ON UPDATE CASCADE, ", name, tableRef)
-		} else if rel == OneToMany {
-			// The field is a one-to-many foreign relation
-			tableRef := strings.Split(field.Type.String(), ".")[1]
+			body += fmt.Sprintf("%[1]v INT UNSIGNED, FOREIGN KEY (%[1]v) REFERENCES %[2]v(id) ON DELETE %[3]v ON UPDATE %[4]v, ", p.name, p.target, p.onDelete, p.onUpdate)
 
-			statements = append(statements, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %[1]v%[2]v(%[1]vID INT UNSIGNED, %[3]v INT UNSIGNED UNIQUE, FOREIGN KEY (%[1]vID) REFERENCES %[1]v(id), FOREIGN KEY (%[3]v) REFERENCES %[2]v(id));", s.table, tableRef, name))
-		} else if rel == ManyToMany {
-			// The field is a many-to-many foreign relation
-			tableRef := strings.Split(field.Type.String(), ".")[1]
-
-			statements = append(statements, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %[1]v%[2]v(%[1]vID INT UNSIGNED, %[3]v INT UNSIGNED, FOREIGN KEY (%[1]vID) REFERENCES %[1]v(id), FOREIGN KEY (%[3]v) REFERENCES %[2]v(id));", s.table, tableRef, name))
+		default:
+			// The field is a one-to-many or many-to-many foreign relation stored in another table
+			str, err := s.createJunctionSQL(p)
+			if err != nil {
+				return statements, err
+			}
+			statements = append(statements, str)
 		}
 	}
 
+	// Add the indexes declared on the struct
+	for _, index := range s.indexes {
+		body += index.definitionSQL() + ", "
+	}
+
 	statements = append(statements, header+body[0:len(body)-2]+footer)
 
 	temp := statements[0]
@@ -387,3 +279,138 @@ func getRelation(field reflect.StructField) RelationType {
 		return UndefinedRelationType
 	}
 }
+
+// getTarget is a helper method that gets the name of the schema a relation field references
+func getTarget(field reflect.StructField) string {
+	// The target of a junction struct is the target of its relation field
+	if target, ok := getJunctionStruct(field); ok {
+		return getTarget(target)
+	}
+
+	// The target of a lazy relation is stored in its type
+	if isLazy(field.Type) {
+		return reflect.New(field.Type).Interface().(lazyRelation).targetType().Name()
+	}
+
+	t := field.Type
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// getActions is a helper method that gets the ON DELETE and ON UPDATE actions of a relation field
+func getActions(field reflect.StructField, rel RelationType) (Action, Action, error) {
+	// Pointer relations cascade by default, while links in another table do nothing by default
+	def := Cascade
+	if rel == OneToMany || rel == ManyToMany {
+		def = NoAction
+	}
+
+	actions := []Action{def, def}
+	for i, tag := range []string{"ondelete", "onupdate"} {
+		val, ok := field.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+
+		switch strings.ToLower(strings.TrimSpace(val)) {
+		case "cascade":
+			actions[i] = Cascade
+
+		case "set null":
+			// A link in another table cannot be set to null, it can only be removed
+			if rel == OneToMany || rel == ManyToMany {
+				return def, def, fmt.Errorf("action 'set null' cannot be used on field '%v' with a slice relation", field.Name)
+			}
+			actions[i] = SetNull
+
+		case "restrict":
+			actions[i] = Restrict
+
+		case "no action":
+			actions[i] = NoAction
+
+		default:
+			return def, def, fmt.Errorf("invalid action '%v' in tag '%v' for field '%v'", val, tag, field.Name)
+		}
+	}
+
+	return actions[0], actions[1], nil
+}
+
+// getJunction is a helper method that gets the name of the table that stores the links of a slice relation field
+func getJunction(table string, field reflect.StructField) string {
+	val, ok := field.Tag.Lookup("through")
+	if ok && val != "" {
+		return val
+	}
+	return table + field.Name
+}
+
+// getInverse is a helper method that gets the relation field an inverse field mirrors
+func getInverse(field reflect.StructField) (string, bool) {
+	val, ok := field.Tag.Lookup("inverse")
+	return val, ok
+}
+
+// referenceSQL gets the SQL value of a one-to-one or many-to-one relation field, which is the ID of the referenced
+// object or NULL
+func (p fieldPlan) referenceSQL(v reflect.Value) (string, error) {
+	id, err := p.referenceValue(v)
+	if err != nil || id == nil {
+		return "NULL", err
+	}
+	return fmt.Sprint(id), nil
+}
+
+// referenceValue gets the value bound to the column of a one-to-one or many-to-one relation field, which is the ID of
+// the referenced object or nil
+func (p fieldPlan) referenceValue(v reflect.Value) (interface{}, error) {
+	// Lazy relations store the ID of their target
+	if p.lazy {
+		ids, err := lazyIDs(p.target, v)
+		if err != nil || len(ids) == 0 {
+			return nil, err
+		}
+		return ids[0], nil
+	}
+
+	// If the object is nil, insert null
+	if v.IsNil() {
+		return nil, nil
+	}
+
+	// Get the schema the object belongs to
+	schema, err := manager.getSchema(p.target)
+	if err != nil {
+		return nil, err
+	}
+
+	// Dereference the object that implements the Readable Interface
+	obj, ok := v.Interface().(Readable)
+	if !ok {
+		return nil, fmt.Errorf("cannot cast schema object as Readable")
+	}
+
+	// Get the ID of the object if it is not nil
+	id, err := schema.getID(obj)
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// getCascade is a helper method that checks if saving an object also saves the objects in its relation field
+func getCascade(field reflect.StructField) (bool, error) {
+	val, ok := field.Tag.Lookup("cascade")
+	if !ok {
+		return false, nil
+	} else if val != "save" {
+		return false, fmt.Errorf("invalid cascade '%v' on field '%v'", val, field.Name)
+	}
+	return true, nil
+}
@@ -0,0 +1,377 @@
+package sql_wrapper
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// Querier runs statements on a database or in a transaction. Both *sql.DB and *sql.Tx implement it, so Read methods
+// can read inside of the Tx an operation was called with
+type Querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// txn is the transaction of one operation, which is a database transaction or a savepoint in a Tx
+type txn interface {
+	Querier
+	Commit() error
+	Rollback() error
+}
+
+// Tx is a transaction on a database. Operations called through a wrapper returned by WithTx run inside of it, and
+// rows locked by LockForUpdate and LockShared stay locked until it ends. Transactions can be nested, in which case
+// the inner transaction is a savepoint of the outer one
+type Tx struct {
+	db         *sql.DB
+	tx         *sql.Tx
+	parent     *Tx     // The transaction the savepoint of a nested transaction belongs to
+	child      *Tx     // The nested transaction in progress
+	name       string  // The name of the savepoint of a nested transaction
+	savepoints *int    // The number of savepoints created in the database transaction, used to name the next one
+	journal    journal // The state of the schemas before the transaction changed them
+	done       bool
+}
+
+// Begin starts a transaction on a database
+func Begin(db *sql.DB) (*Tx, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tx{db: db, tx: tx, savepoints: new(int)}, nil
+}
+
+// Begin starts a transaction nested inside of the transaction with a savepoint. Rolling it back only rolls back the
+// statements and identity map changes made since it began. The transaction cannot be used until the nested
+// transaction ends
+func (t *Tx) Begin() (*Tx, error) {
+	if err := t.check(); err != nil {
+		return nil, err
+	}
+
+	sp, err := t.newSavepoint()
+	if err != nil {
+		return nil, err
+	}
+
+	t.child = &Tx{db: t.db, tx: t.tx, parent: t, name: sp.name, savepoints: t.savepoints}
+	return t.child, nil
+}
+
+// Commit commits the transaction and releases its locks. Nested transactions release their savepoint, so their
+// statements are committed with the outer transaction
+func (t *Tx) Commit() error {
+	if err := t.check(); err != nil {
+		return err
+	}
+	t.end()
+
+	if t.parent != nil {
+		if _, err := t.tx.Exec("RELEASE SAVEPOINT " + t.name + ";"); err != nil {
+			return err
+		}
+
+		// The outer transaction restores the changes if it is rolled back
+		t.parent.journal.merge(t.journal)
+		return nil
+	}
+	return t.tx.Commit()
+}
+
+// Rollback rolls back the transaction and releases its locks. Schemas get back the objects they held when the
+// transaction began, and the fields the operations in the transaction changed are restored. Fields the operations
+// wrote keep their values, so those changes are pending again
+func (t *Tx) Rollback() error {
+	if err := t.check(); err != nil {
+		return err
+	}
+	t.end()
+
+	var err error
+	if t.parent != nil {
+		_, err = t.tx.Exec("ROLLBACK TO SAVEPOINT " + t.name + ";")
+	} else {
+		err = t.tx.Rollback()
+	}
+
+	t.journal.restore()
+	if err != nil {
+		return err
+	}
+
+	// Update inverse fields that mirror the restored schemas
+	return manager.refreshInverses()
+}
+
+// check is a helper method that makes sure the transaction is in progress and does not have a nested transaction in
+// progress
+func (t *Tx) check() error {
+	if t.done {
+		return sql.ErrTxDone
+	} else if t.child != nil {
+		return fmt.Errorf("nested transaction is still in progress")
+	}
+	return nil
+}
+
+// end is a helper method that ends the transaction, so its parent can be used again
+func (t *Tx) end() {
+	t.done = true
+
+	if t.parent != nil {
+		t.parent.child = nil
+	}
+}
+
+// savepoint is the transaction of an operation that runs inside of a Tx, so a failed operation only rolls back its
+// own statements
+type savepoint struct {
+	*sql.Tx
+	name string
+}
+
+// newSavepoint creates a savepoint in a transaction
+func (t *Tx) newSavepoint() (*savepoint, error) {
+	*t.savepoints++
+	sp := &savepoint{Tx: t.tx, name: fmt.Sprintf("sp%v", *t.savepoints)}
+
+	if _, err := t.tx.Exec("SAVEPOINT " + sp.name + ";"); err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+// Commit releases the savepoint, keeping its statements in the transaction
+func (sp *savepoint) Commit() error {
+	_, err := sp.Exec("RELEASE SAVEPOINT " + sp.name + ";")
+	return err
+}
+
+// Rollback rolls back the statements executed since the savepoint
+func (sp *savepoint) Rollback() error {
+	_, err := sp.Exec("ROLLBACK TO SAVEPOINT " + sp.name + ";")
+	return err
+}
+
+// checkTx is a helper method that makes sure an operation on the schema can run in a transaction. Operations called
+// without a transaction pass nil
+func (s *schema) checkTx(tx *Tx) error {
+	if tx == nil {
+		return nil
+	} else if tx.db != s.db {
+		return fmt.Errorf("transaction is not on the database of schema '%v'", s.name())
+	}
+	return tx.check()
+}
+
+// begin starts the transaction of an operation. Operations called with a Tx run in a savepoint of the Tx, and other
+// operations run in their own database transaction
+func (s *schema) begin(tx *Tx) (txn, error) {
+	if err := s.checkTx(tx); err != nil {
+		return nil, err
+	}
+
+	if tx != nil {
+		sp, err := tx.newSavepoint()
+		if err != nil {
+			return nil, err
+		}
+		return sp, nil
+	}
+
+	t, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// conn gets where an operation on the schema reads from, which is the Tx the operation was called with if there is one
+func (s *schema) conn(tx *Tx) Querier {
+	if tx != nil {
+		return tx.tx
+	}
+	return s.db
+}
+
+// journal records the state of the schemas before a transaction changed them, so rolling back the transaction can
+// restore it. Only the first change to each entry is recorded, and the journal grows with the changes made in the
+// transaction rather than with the number of loaded objects
+type journal struct {
+	schemas map[*schema]schemaEntry          // The ID counter of every changed schema
+	entries map[*schema]map[int]journalEntry // The identity map entries of every changed schema
+	fields  map[fieldKey]reflect.Value       // The values of changed fields
+}
+
+// schemaEntry is the state of a schema recorded in a journal
+type schemaEntry struct {
+	nextID int
+	loaded bool
+}
+
+// journalEntry is an identity map entry recorded in a journal
+type journalEntry struct {
+	object Readable  // The object with the ID, or nil if there was none
+	state  persisted // The state of the object
+	stated bool      // Whether the object had a state
+}
+
+// fieldKey identifies a field of an object
+type fieldKey struct {
+	object Readable
+	index  int
+}
+
+// touch records the schema and the identity map entries with the given IDs before an operation in the transaction
+// changes them. Operations called without a transaction pass nil, which records nothing
+func (t *Tx) touch(s *schema, ids ...int) {
+	if t == nil {
+		return
+	}
+	j := &t.journal
+
+	if j.schemas == nil {
+		j.schemas = make(map[*schema]schemaEntry)
+		j.entries = make(map[*schema]map[int]journalEntry)
+	}
+
+	if _, ok := j.schemas[s]; !ok {
+		j.schemas[s] = schemaEntry{nextID: s.nextID, loaded: s.loaded}
+		j.entries[s] = make(map[int]journalEntry)
+	}
+
+	for _, id := range ids {
+		if _, ok := j.entries[s][id]; ok {
+			continue
+		}
+
+		entry := journalEntry{}
+		if obj, ok := s.objects[id]; ok {
+			entry.object = obj.Object()
+		}
+		entry.state, entry.stated = s.states[id]
+		j.entries[s][id] = entry
+	}
+}
+
+// touchAll records the schema and every identity map entry of the schema
+func (t *Tx) touchAll(s *schema) {
+	if t == nil {
+		return
+	}
+
+	ids := make([]int, 0, len(s.objects))
+	for id := range s.objects {
+		ids = append(ids, id)
+	}
+	t.touch(s, ids...)
+}
+
+// recordField records a field of an object before an operation in the transaction changes it
+func (t *Tx) recordField(obj Readable, index int) {
+	if t == nil {
+		return
+	}
+	t.recordValue(obj, index, reflect.ValueOf(obj).Elem().Field(index))
+}
+
+// recordFields records the stored fields of an object before an operation in the transaction reads them
+func (t *Tx) recordFields(s *schema, obj Readable) {
+	if t == nil {
+		return
+	}
+
+	for _, p := range s.plan {
+		if !p.skip {
+			t.recordField(obj, p.index)
+		}
+	}
+}
+
+// recordValue records the value a field of an object had before an operation in the transaction changed it. Slices
+// are copied since operations can reorder their elements in place
+func (t *Tx) recordValue(obj Readable, index int, value reflect.Value) {
+	if t == nil {
+		return
+	}
+	j := &t.journal
+
+	if j.fields == nil {
+		j.fields = make(map[fieldKey]reflect.Value)
+	}
+
+	key := fieldKey{object: obj, index: index}
+	if _, ok := j.fields[key]; ok {
+		return
+	}
+
+	copy := reflect.New(value.Type()).Elem()
+	if value.Kind() == reflect.Slice && !value.IsNil() {
+		copy.Set(reflect.MakeSlice(value.Type(), value.Len(), value.Len()))
+		reflect.Copy(copy, value)
+	} else {
+		copy.Set(value)
+	}
+	j.fields[key] = copy
+}
+
+// merge adds the entries of a nested transaction's journal that are not recorded yet
+func (j *journal) merge(nested journal) {
+	for s, entry := range nested.schemas {
+		if j.schemas == nil {
+			j.schemas = make(map[*schema]schemaEntry)
+			j.entries = make(map[*schema]map[int]journalEntry)
+		}
+
+		if _, ok := j.schemas[s]; !ok {
+			j.schemas[s] = entry
+			j.entries[s] = make(map[int]journalEntry)
+		}
+
+		for id, e := range nested.entries[s] {
+			if _, ok := j.entries[s][id]; !ok {
+				j.entries[s][id] = e
+			}
+		}
+	}
+
+	for key, value := range nested.fields {
+		if j.fields == nil {
+			j.fields = make(map[fieldKey]reflect.Value)
+		}
+
+		if _, ok := j.fields[key]; !ok {
+			j.fields[key] = value
+		}
+	}
+}
+
+// restore sets the recorded fields and identity map entries back to their recorded state
+func (j *journal) restore() {
+	for key, value := range j.fields {
+		reflect.ValueOf(key.object).Elem().Field(key.index).Set(value)
+	}
+
+	for s, entry := range j.schemas {
+		for id, e := range j.entries[s] {
+			s.remove(id)
+			if e.object == nil {
+				continue
+			}
+
+			s.add(id, e.object)
+			if e.stated {
+				s.states[id] = e.state
+			}
+		}
+
+		s.nextID = entry.nextID
+		s.loaded = entry.loaded
+	}
+
+	*j = journal{}
+}
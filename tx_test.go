@@ -0,0 +1,203 @@
+package sql_wrapper_test
+
+import (
+	"testing"
+
+	sql_wrapper "github.com/ethanbaker/sql-wrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Tests ----------
+
+func TestTx(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	tx, err := sql_wrapper.Begin(database)
+	assert.Nil(err)
+	txWrapper := wrapper.WithTx(tx)
+
+	// Operations run in the transaction, and a failed operation only rolls back its own statements
+	first := TestObject{Name: "Jack", Weather: Summer}
+	_, err = txWrapper.Insert(&first)
+	assert.Nil(err)
+
+	second := TestObject{Name: "Jill", Weather: "Not a season"}
+	_, err = txWrapper.Insert(&second)
+	assert.NotNil(err)
+
+	var count int
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM TestObject").Scan(&count))
+	assert.Equal(0, count)
+
+	assert.Nil(tx.Commit())
+	assert.NotNil(tx.Commit())
+
+	// Operations cannot run in a transaction that ended
+	_, err = txWrapper.Insert(&TestObject{Name: "Jill", Weather: Autumn})
+	assert.NotNil(err)
+
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM TestObject").Scan(&count))
+	assert.Equal(1, count)
+}
+
+func TestTxRollback(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	tx, err := sql_wrapper.Begin(database)
+	assert.Nil(err)
+
+	obj := TestObject{Name: "Jack", Weather: Summer}
+	_, err = wrapper.WithTx(tx).Insert(&obj)
+	assert.Nil(err)
+
+	assert.Nil(tx.Rollback())
+
+	var count int
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM TestObject").Scan(&count))
+	assert.Equal(0, count)
+
+	// The inserted object is removed from the schema
+	_, err = wrapper.GetID(&obj)
+	assert.NotNil(err)
+}
+
+func TestNestedTx(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	outer, err := sql_wrapper.Begin(database)
+	assert.Nil(err)
+
+	first := TestObject{Name: "Jack", Weather: Summer}
+	_, err = wrapper.WithTx(outer).Insert(&first)
+	assert.Nil(err)
+
+	// Beginning on a transaction nests a transaction inside of it
+	inner, err := outer.Begin()
+	assert.Nil(err)
+
+	second := TestObject{Name: "Jill", Weather: Autumn}
+	_, err = wrapper.WithTx(inner).Insert(&second)
+	assert.Nil(err)
+
+	// The outer transaction cannot be used or end before the nested one
+	_, err = wrapper.WithTx(outer).Insert(&TestObject{Name: "John", Weather: Winter})
+	assert.NotNil(err)
+	assert.NotNil(outer.Commit())
+
+	// Rolling back the nested transaction discards its objects only
+	assert.Nil(inner.Rollback())
+
+	_, err = wrapper.GetID(&second)
+	assert.NotNil(err)
+	_, err = wrapper.GetID(&first)
+	assert.Nil(err)
+
+	// Committed nested transactions keep their changes
+	inner, err = outer.Begin()
+	assert.Nil(err)
+
+	first.Age = 21
+	assert.Nil(wrapper.WithTx(inner).Update(&first))
+	assert.Nil(inner.Commit())
+	assert.Nil(outer.Commit())
+
+	var (
+		count int
+		age   int
+	)
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM TestObject").Scan(&count))
+	assert.Equal(1, count)
+	assert.Nil(database.QueryRow("SELECT Age FROM TestObject WHERE Name = 'Jack'").Scan(&age))
+	assert.Equal(21, age)
+}
+
+func TestNestedTxRollbackState(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	obj := TestObject{Name: "Jack", Age: 20, Weather: Summer}
+	_, err := wrapper.Insert(&obj)
+	assert.Nil(err)
+
+	outer, err := sql_wrapper.Begin(database)
+	assert.Nil(err)
+	inner, err := outer.Begin()
+	assert.Nil(err)
+
+	obj.Age = 21
+	assert.Nil(wrapper.WithTx(inner).Update(&obj))
+	assert.Nil(inner.Rollback())
+
+	// The rolled back update is pending again
+	changes, err := wrapper.Changes(&obj)
+	assert.Nil(err)
+	assert.Equal([]sql_wrapper.FieldChange{{Field: "Age", Column: "Age", Old: "20", New: "21"}}, changes)
+
+	assert.Nil(outer.Rollback())
+}
+
+func TestTxRead(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	tx, err := sql_wrapper.Begin(database)
+	assert.Nil(err)
+	txWrapper := wrapper.WithTx(tx)
+
+	obj := TestObject{Name: "Jack", Age: 20, Weather: Summer}
+	id, err := txWrapper.Insert(&obj)
+	assert.Nil(err)
+
+	// Refreshing in the transaction reads its uncommitted rows, so the object stays loaded
+	assert.Nil(txWrapper.Refresh())
+
+	objects, err := txWrapper.Get()
+	assert.Nil(err)
+	assert.Equal(1, len(objects))
+	assert.True(objects[id] == &obj)
+
+	// Operations without the transaction do not join it
+	other := TestObject{Name: "Jill", Age: 30, Weather: Autumn}
+	_, err = wrapper.Insert(&other)
+	assert.Nil(err)
+
+	assert.Nil(tx.Rollback())
+
+	var count int
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM TestObject").Scan(&count))
+	assert.Equal(1, count)
+}
+
+func TestTxRollbackFields(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	obj := TestObject{Name: "Jack", Age: 20, Weather: Summer}
+	id, err := wrapper.Insert(&obj)
+	assert.Nil(err)
+
+	tx, err := sql_wrapper.Begin(database)
+	assert.Nil(err)
+	txWrapper := wrapper.WithTx(tx)
+
+	// Fields set by operations in the transaction are restored when it rolls back
+	assert.Nil(txWrapper.Increment(&obj, "Age", 5))
+	assert.Equal(25, obj.Age)
+
+	_, err = txWrapper.UpdateWhere("id = ?", map[string]interface{}{"Name": "Jill"}, id)
+	assert.Nil(err)
+	assert.Nil(txWrapper.Reload(&obj))
+	assert.Equal("Jill", obj.Name)
+
+	assert.Nil(tx.Rollback())
+	assert.Equal(20, obj.Age)
+	assert.Equal("Jack", obj.Name)
+
+	// The object matches the database again
+	changes, err := wrapper.Changes(&obj)
+	assert.Nil(err)
+	assert.Empty(changes)
+}
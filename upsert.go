@@ -0,0 +1,247 @@
+package sql_wrapper
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// keyPlans is a helper method that gets the plans of the fields of a unique key. The fields must match the columns
+// of a unique index so a duplicate row is found by the key it conflicts on
+func (s *schema) keyPlans(fields []string) ([]fieldPlan, error) {
+	plans := []fieldPlan{}
+
+	if len(fields) == 0 {
+		return plans, fmt.Errorf("no key fields given")
+	}
+
+	columns := []string{}
+	for _, name := range fields {
+		p, err := s.storedPlan(name)
+		if err != nil {
+			return plans, err
+		} else if p.list() {
+			return plans, fmt.Errorf("field '%v' is not stored in a column of the table", name)
+		}
+
+		plans = append(plans, p)
+		columns = append(columns, p.name)
+	}
+	sort.Strings(columns)
+
+	for _, index := range s.indexes {
+		if !index.Unique || len(index.Columns) != len(columns) {
+			continue
+		}
+
+		indexColumns := append([]string{}, index.Columns...)
+		sort.Strings(indexColumns)
+		if strings.Join(indexColumns, ",") == strings.Join(columns, ",") {
+			return plans, nil
+		}
+	}
+
+	return plans, fmt.Errorf("fields %v do not match a unique index", fields)
+}
+
+// onConflict is a helper method that checks if the driver of a database writes upserts with ON CONFLICT, which
+// PostgreSQL and SQLite use instead of ON DUPLICATE KEY UPDATE
+func onConflict(db *sql.DB) bool {
+	if db == nil {
+		return false
+	}
+
+	name := strings.ToLower(reflect.TypeOf(db.Driver()).String())
+	for _, dialect := range []string{"pq.", "pgx", "postgres", "sqlite"} {
+		if strings.Contains(name, dialect) {
+			return true
+		}
+	}
+	return false
+}
+
+// upsertRowSQL creates a string that will insert the row of an object, or update every column of the row with the
+// same key. The ID of the row is left to the database, so an insert never takes the ID of another row, and the query
+// returns the ID of the row it wrote
+func (s *schema) upsertRowSQL(plans []fieldPlan, obj Readable) (statement, error) {
+	// Make sure the table name is set
+	if s.table == "" {
+		return statement{}, fmt.Errorf("cannot insert record with no table name")
+	}
+
+	columns, values, err := s.rowSQL(-1, reflect.ValueOf(obj).Elem())
+	if err != nil {
+		return statement{}, err
+	}
+	columns, values = columns[1:], values[1:]
+
+	if onConflict(s.db) {
+		keys := []string{}
+		for _, p := range plans {
+			keys = append(keys, p.name)
+		}
+
+		// RETURNING gives the ID of the row whether it was inserted or updated
+		updates := []string{}
+		for _, column := range columns {
+			updates = append(updates, fmt.Sprintf("%[1]v = excluded.%[1]v", column))
+		}
+
+		return statement{query: fmt.Sprintf("INSERT INTO %v (%v) VALUES %v ON CONFLICT (%v) DO UPDATE SET %v RETURNING id;", s.table, strings.Join(columns, ", "), placeholders(len(values)), strings.Join(keys, ", "), strings.Join(updates, ", ")), args: values}, nil
+	}
+
+	// LAST_INSERT_ID(id) makes the ID of an updated row the insert ID of the statement
+	updates := []string{"id = LAST_INSERT_ID(id)"}
+	for _, column := range columns {
+		updates = append(updates, fmt.Sprintf("%[1]v = VALUES(%[1]v)", column))
+	}
+
+	return statement{query: fmt.Sprintf("INSERT INTO %v (%v) VALUES %v ON DUPLICATE KEY UPDATE %v;", s.table, strings.Join(columns, ", "), placeholders(len(values)), strings.Join(updates, ", ")), args: values}, nil
+}
+
+// upsertRow writes the row of an object in a transaction and returns the ID of the row
+func (s *schema) upsertRow(q Querier, plans []fieldPlan, obj Readable) (int, error) {
+	str, err := s.upsertRowSQL(plans, obj)
+	if err != nil {
+		return -1, err
+	}
+
+	id := -1
+	if onConflict(s.db) {
+		err = q.QueryRow(str.query, str.args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := q.Exec(str.query, str.args...)
+	if err != nil {
+		return id, err
+	}
+
+	insertID, err := result.LastInsertId()
+	if err != nil {
+		return id, err
+	}
+	return int(insertID), nil
+}
+
+// upsertLinksSQL creates strings that will write the links of the slice relations of an upserted object. Only the
+// links that differ from the ones of the row are changed, and a new row has none
+func (s *schema) upsertLinksSQL(q Querier, id int, obj Readable) ([]statement, error) {
+	statements := []statement{}
+	v := reflect.ValueOf(obj).Elem()
+
+	for _, p := range s.plan {
+		if p.skip || !p.list() {
+			continue
+		}
+
+		strs, err := s.updateLinksSQL(q, id, p.field, v.Field(p.index))
+		if err != nil {
+			return statements, err
+		}
+		statements = append(statements, strs...)
+	}
+
+	return statements, nil
+}
+
+// upsert inserts an object, or updates the row with the same unique key. If the row is loaded, its object is updated
+// with the fields of the given object and returned instead
+func (s *schema) upsert(tx *Tx, val Readable, fields []string) (Readable, error) {
+	// Registered objects already have a row
+	if _, err := s.validate(val); err == nil {
+		return val, s.update(tx, val)
+	}
+
+	plans, err := s.keyPlans(fields)
+	if err != nil {
+		return val, err
+	}
+
+	if _, err := identityKey(val); err != nil {
+		return val, err
+	}
+
+	// Start a transaction in the database
+	t, err := s.begin(tx)
+	if err != nil {
+		return val, err
+	}
+
+	id, err := s.upsertRow(t, plans, val)
+	if err != nil {
+		t.Rollback()
+		return val, err
+	}
+
+	obj := val
+
+	var snapshot reflect.Value
+	if existing, ok := s.objects[id]; ok {
+		// Update the loaded object instead of registering a duplicate
+		obj = existing.Object()
+		snapshot = reflect.New(reflect.TypeOf(obj).Elem()).Elem()
+		snapshot.Set(reflect.ValueOf(obj).Elem())
+
+		tx.touch(s, id)
+		for _, p := range s.plan {
+			tx.recordField(obj, p.index)
+		}
+		reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(val).Elem())
+	} else {
+		// The row may have been inserted with an ID the schema has not handed out yet
+		if id >= s.nextID {
+			s.nextID = id + 1
+		}
+
+		tx.touch(s, id)
+		s.add(id, val)
+	}
+
+	// Rollback the transaction and restore the schema and the owners if there is an error
+	var owners []saveStep
+	defer func() {
+		if err != nil {
+			t.Rollback()
+			if snapshot.IsValid() {
+				reflect.ValueOf(obj).Elem().Set(snapshot)
+			} else {
+				s.remove(id)
+			}
+			restoreSteps(owners)
+		}
+	}()
+
+	strs, err := s.upsertLinksSQL(t, id, obj)
+	if err != nil {
+		return val, err
+	}
+
+	// Save owners that reference the object through inverse fields
+	var inverseStrs []statement
+	inverseStrs, owners, err = s.pushInversesSQL(tx, t, obj)
+	if err != nil {
+		return val, err
+	}
+	strs = append(strs, inverseStrs...)
+
+	for _, str := range strs {
+		_, err = t.Exec(str.query, str.args...)
+		if err != nil {
+			return val, err
+		}
+	}
+
+	if err = t.Commit(); err != nil {
+		return val, err
+	}
+	s.persist(id)
+	persistSteps(owners)
+
+	// Update inverse fields that mirror the schema
+	manager.syncInverses(s, obj)
+	manager.syncSteps(owners)
+	return obj, nil
+}
@@ -0,0 +1,71 @@
+package sql_wrapper_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Tests ----------
+
+func TestUpsert(t *testing.T) {
+	indexSetup()
+	assert := assert.New(t)
+
+	// Upserting a new key inserts the object
+	obj1 := IndexedObject{Author: "Jack", Type: "Original", Email: "jack@example.com", Priority: 1}
+	result, err := indexedWrapper.Upsert(&obj1, "Email")
+	assert.Nil(err)
+	assert.Equal(&obj1, result)
+
+	id, err := indexedWrapper.GetID(&obj1)
+	assert.Nil(err)
+
+	// Upserting an object with the same key updates the loaded object instead of inserting a duplicate
+	obj2 := IndexedObject{Author: "John", Type: "Comment", Email: "jack@example.com", Priority: 2}
+	result, err = indexedWrapper.Upsert(&obj2, "Email")
+	assert.Nil(err)
+	assert.True(result == &obj1)
+	assert.Equal("John", obj1.Author)
+
+	_, err = indexedWrapper.GetID(&obj2)
+	assert.NotNil(err)
+
+	var (
+		count  int
+		author string
+	)
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM IndexedObject").Scan(&count))
+	assert.Equal(1, count)
+	assert.Nil(database.QueryRow("SELECT Author FROM IndexedObject WHERE id = ?", id).Scan(&author))
+	assert.Equal("John", author)
+
+	// Key fields must match a unique index
+	obj3 := IndexedObject{Author: "Jill", Email: "jill@example.com"}
+	_, err = indexedWrapper.Upsert(&obj3, "Author")
+	assert.NotNil(err)
+	_, err = indexedWrapper.Upsert(&obj3)
+	assert.NotNil(err)
+}
+
+func TestUpsertUnloaded(t *testing.T) {
+	indexSetup()
+	assert := assert.New(t)
+
+	_, err := database.Exec("INSERT INTO IndexedObject (id, Author, Type, Email, Priority) VALUES (7, 'Jack', 'Original', 'jack@example.com', 1)")
+	assert.Nil(err)
+
+	// Upserting a key of a row that is not loaded registers the object with the ID of the row
+	obj := IndexedObject{Author: "John", Type: "Comment", Email: "jack@example.com", Priority: 2}
+	result, err := indexedWrapper.Upsert(&obj, "Email")
+	assert.Nil(err)
+	assert.True(result == &obj)
+
+	id, err := indexedWrapper.GetID(&obj)
+	assert.Nil(err)
+	assert.Equal(7, id)
+
+	var priority int
+	assert.Nil(database.QueryRow("SELECT Priority FROM IndexedObject WHERE id = 7").Scan(&priority))
+	assert.Equal(2, priority)
+}
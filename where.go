@@ -0,0 +1,229 @@
+package sql_wrapper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// whereIDs reads and locks the IDs of the rows that match a condition in a transaction
+func (s *schema) whereIDs(q Querier, cond string, args []interface{}) ([]int, error) {
+	ids := []int{}
+
+	if strings.TrimSpace(cond) == "" {
+		return ids, fmt.Errorf("condition cannot be empty")
+	}
+
+	rows, err := q.Query(fmt.Sprintf("SELECT id FROM %v WHERE %v FOR UPDATE;", s.table, cond), args...)
+	if err != nil {
+		return ids, err
+	}
+	defer rows.Close()
+
+	var id int
+	for rows.Next() {
+		if err := rows.Scan(&id); err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// idList is a helper method that joins IDs into a list for an IN clause
+func idList(ids []int) string {
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = fmt.Sprint(id)
+	}
+
+	return strings.Join(strs, ", ")
+}
+
+// deleteWhere deletes every entry that matches a condition and returns the number of deleted entries
+func (s *schema) deleteWhere(tx *Tx, cond string, args []interface{}) (int, error) {
+	// Start a transaction in the database
+	t, err := s.begin(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	// Rollback the transaction if there is an error
+	defer func() {
+		if err != nil {
+			t.Rollback()
+		}
+	}()
+
+	ids, err := s.whereIDs(t, cond, args)
+	if err != nil || len(ids) == 0 {
+		if err == nil {
+			err = t.Commit()
+		}
+		return 0, err
+	}
+
+	// Remove the links of the entries before the entries
+	for _, p := range s.plan {
+		if p.list() {
+			if _, err = t.Exec(fmt.Sprintf("DELETE FROM %v WHERE %vID IN (%v);", p.junction, s.table, idList(ids))); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	// Delete the locked rows rather than matching the condition again, so the rows match the evicted entries
+	if _, err = t.Exec(fmt.Sprintf("DELETE FROM %v WHERE id IN (%v);", s.table, idList(ids))); err != nil {
+		return 0, err
+	}
+
+	if err = t.Commit(); err != nil {
+		return 0, err
+	}
+
+	// Remove the objects from the internal map and update objects that referenced them
+	for _, id := range ids {
+		obj, ok := s.objects[id]
+		if !ok {
+			// Lazy relations can reference rows that were never read
+			manager.propagateDelete(tx, s, nil, id)
+			continue
+		}
+
+		tx.touch(s, id)
+		s.remove(id)
+		manager.syncInverses(s, obj.Object())
+		manager.propagateDelete(tx, s, obj.Object(), id)
+	}
+
+	return len(ids), nil
+}
+
+// bulkChange is a column set by a bulk update
+type bulkChange struct {
+	plan  fieldPlan     // The plan of the field the column stores
+	value reflect.Value // The new value of the field
+}
+
+// bulkChanges is a helper method that checks the changes of a bulk update, which map struct field names to new values
+func (s *schema) bulkChanges(values map[string]interface{}) ([]bulkChange, error) {
+	changes := []bulkChange{}
+
+	if len(values) == 0 {
+		return changes, fmt.Errorf("no changes given")
+	}
+
+	// Sort the fields so statements are the same for the same changes
+	names := []string{}
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p, err := s.storedPlan(name)
+		if err != nil {
+			return changes, err
+		} else if p.rel != UndefinedRelationType {
+			return changes, fmt.Errorf("field '%v' is a relation and cannot be changed in bulk", name)
+		}
+
+		value, err := convertValue(values[name], p.field.Type)
+		if err != nil {
+			return changes, fmt.Errorf("field '%v': %v", name, err)
+		}
+
+		changes = append(changes, bulkChange{plan: p, value: value})
+	}
+
+	return changes, nil
+}
+
+// convertValue is a helper method that converts a value to the type of a field
+func convertValue(value interface{}, t reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("cannot set nil value")
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+
+	// Numbers are not converted to strings since the conversion makes a rune
+	if v.Type().ConvertibleTo(t) && (t.Kind() != reflect.String || v.Kind() == reflect.String) {
+		return v.Convert(t), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("cannot use value of type %v as %v", v.Type(), t)
+}
+
+// updateWhere sets fields of every entry that matches a condition and returns the number of updated entries
+func (s *schema) updateWhere(tx *Tx, cond string, values map[string]interface{}, args []interface{}) (int, error) {
+	changes, err := s.bulkChanges(values)
+	if err != nil {
+		return 0, err
+	}
+
+	// Start a transaction in the database
+	t, err := s.begin(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	// Rollback the transaction if there is an error
+	defer func() {
+		if err != nil {
+			t.Rollback()
+		}
+	}()
+
+	ids, err := s.whereIDs(t, cond, args)
+	if err != nil || len(ids) == 0 {
+		if err == nil {
+			err = t.Commit()
+		}
+		return 0, err
+	}
+
+	columns := []string{}
+	params := []interface{}{}
+	for _, c := range changes {
+		columns = append(columns, c.plan.name+" = ?")
+		params = append(params, c.value.Interface())
+	}
+
+	// Update the locked rows rather than matching the condition again, so the rows match the patched entries
+	if _, err = t.Exec(fmt.Sprintf("UPDATE %v SET %v WHERE id IN (%v);", s.table, strings.Join(columns, ", "), idList(ids)), params...); err != nil {
+		return 0, err
+	}
+
+	if err = t.Commit(); err != nil {
+		return 0, err
+	}
+
+	// Patch the loaded objects
+	for _, id := range ids {
+		obj, ok := s.objects[id]
+		if !ok {
+			continue
+		}
+
+		tx.touch(s, id)
+
+		v := reflect.ValueOf(obj.Object()).Elem()
+		for _, c := range changes {
+			tx.recordField(obj.Object(), c.plan.index)
+			v.Field(c.plan.index).Set(c.value)
+			s.persistField(id, c.plan.index)
+		}
+	}
+
+	return len(ids), nil
+}
@@ -0,0 +1,100 @@
+package sql_wrapper_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Tests ----------
+
+func TestDeleteWhere(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	objs := []*TestObject{
+		{Name: "Jack", Age: 19, Weather: Summer},
+		{Name: "Jill", Age: 21, Weather: Autumn},
+		{Name: "John", Age: 22, Weather: Winter},
+	}
+	_, err := wrapper.InsertMany(objs)
+	assert.Nil(err)
+
+	count, err := wrapper.DeleteWhere("Age > ?", 20)
+	assert.Nil(err)
+	assert.Equal(2, count)
+
+	// Deleted objects are removed from the wrapper
+	_, err = wrapper.GetID(objs[0])
+	assert.Nil(err)
+	_, err = wrapper.GetID(objs[1])
+	assert.NotNil(err)
+	_, err = wrapper.GetID(objs[2])
+	assert.NotNil(err)
+
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM TestObject").Scan(&count))
+	assert.Equal(1, count)
+
+	// Conditions that match nothing delete nothing
+	count, err = wrapper.DeleteWhere("Age > ?", 30)
+	assert.Nil(err)
+	assert.Equal(0, count)
+
+	// Conditions cannot be empty
+	_, err = wrapper.DeleteWhere("")
+	assert.NotNil(err)
+}
+
+func TestDeleteWhereLinks(t *testing.T) {
+	cascadeSetup()
+	assert := assert.New(t)
+
+	cars := []*Car{{Model: "Coupe"}, {Model: "Sedan"}}
+	_, err := carWrapper.InsertMany(cars)
+	assert.Nil(err)
+
+	garages := []*Garage{{Name: "Main", Cars: cars[:1]}, {Name: "Side", Cars: cars[1:]}}
+	_, err = garageWrapper.InsertMany(garages)
+	assert.Nil(err)
+
+	// The links of deleted objects are removed with them
+	count, err := garageWrapper.DeleteWhere("Name = ?", "Main")
+	assert.Nil(err)
+	assert.Equal(1, count)
+
+	assert.Nil(database.QueryRow("SELECT COUNT(*) FROM GarageCars").Scan(&count))
+	assert.Equal(1, count)
+}
+
+func TestUpdateWhere(t *testing.T) {
+	setup()
+	assert := assert.New(t)
+
+	objs := []*TestObject{
+		{Name: "Jack", Age: 19, Weather: Summer},
+		{Name: "Jill", Age: 21, Weather: Autumn},
+	}
+	_, err := wrapper.InsertMany(objs)
+	assert.Nil(err)
+
+	count, err := wrapper.UpdateWhere("Age < ?", map[string]interface{}{"Weather": "Winter", "Age": 20}, 20)
+	assert.Nil(err)
+	assert.Equal(1, count)
+
+	// Loaded objects are patched
+	assert.Equal(Winter, objs[0].Weather)
+	assert.Equal(20, objs[0].Age)
+	assert.Equal(Autumn, objs[1].Weather)
+
+	var weather Season
+	assert.Nil(database.QueryRow("SELECT Weather FROM TestObject WHERE Name = ?", "Jack").Scan(&weather))
+	assert.Equal(Winter, weather)
+
+	// Fields must exist, be stored and hold the new values
+	_, err = wrapper.UpdateWhere("Age < ?", map[string]interface{}{"Missing": 1}, 20)
+	assert.NotNil(err)
+	_, err = wrapper.UpdateWhere("Age < ?", map[string]interface{}{"Hidden": "abc"}, 20)
+	assert.NotNil(err)
+	_, err = wrapper.UpdateWhere("Age < ?", map[string]interface{}{"Name": 1}, 20)
+	assert.NotNil(err)
+}
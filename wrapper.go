@@ -8,6 +8,12 @@ import (
 // Wrapper wraps around a schema so you can call functions with defined types
 type Wrapper[T Readable] struct {
 	schema *schema
+	tx     *Tx // The transaction operations run in, or nil to run every operation in its own transaction
+}
+
+// WithTx returns a wrapper around the same schema whose operations run in the given transaction
+func (w *Wrapper[T]) WithTx(tx *Tx) *Wrapper[T] {
+	return &Wrapper[T]{schema: w.schema, tx: tx}
 }
 
 // Name returns the name of the table the schema represents
@@ -15,9 +21,21 @@ func (w *Wrapper[T]) Name() string {
 	return w.schema.name()
 }
 
-// Save makes sure an object is registered to the schema and returns its ID
+// Indexes returns the indexes and unique constraints declared on the schema
+func (w *Wrapper[T]) Indexes() []Index {
+	indexes := make([]Index, len(w.schema.indexes))
+	for i, index := range w.schema.indexes {
+		index.Columns = append([]string{}, index.Columns...)
+		indexes[i] = index
+	}
+
+	return indexes
+}
+
+// Save makes sure an object is registered to the schema and returns its ID. Objects referenced through relation
+// fields with the 'cascade' tag are saved with it in one transaction
 func (w *Wrapper[T]) Save(val T) error {
-	return w.schema.Save(val)
+	return w.schema.Save(w.tx, val)
 }
 
 // Get gets the objects currently loaded
@@ -66,24 +84,197 @@ func (w *Wrapper[T]) GetByID(id int) (T, error) {
 	return obj, nil
 }
 
+// Load gets an object by ID, reading it from the database if it is not loaded
+func (w *Wrapper[T]) Load(id int) (T, error) {
+	var obj T
+
+	val, err := w.schema.loadByID(w.tx, id)
+	if err != nil {
+		return obj, err
+	}
+
+	// Cast the object to the generic type and return
+	obj, ok := val.(T)
+	if !ok {
+		return obj, fmt.Errorf("cannot cast object with given id to custom type")
+	}
+
+	return obj, nil
+}
+
+// ReferencedBy finds the objects in every schema that reference an object, grouped by schema and relation field
+func (w *Wrapper[T]) ReferencedBy(val T) ([]Referrer, error) {
+	return manager.referencedBy(w.tx, w.schema, val)
+}
+
 // Insert inserts a new entry and returns the ID of the new entry
 func (w *Wrapper[T]) Insert(val T) (int, error) {
-	return w.schema.insert(val)
+	return w.schema.insert(w.tx, val)
 }
 
-// Update updates an entry and returns the old object
+// InsertMany inserts new entries in one transaction with multi-row statements and returns their IDs in the order
+// of the objects
+func (w *Wrapper[T]) InsertMany(vals []T) ([]int, error) {
+	readables := make([]Readable, len(vals))
+	for i, val := range vals {
+		readables[i] = val
+	}
+
+	return w.schema.insertMany(w.tx, readables)
+}
+
+// SetBatch sets how batch operations group rows into statements
+func (w *Wrapper[T]) SetBatch(config BatchConfig) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+
+	w.schema.batch = config
+	return nil
+}
+
+// Upsert inserts an entry, or updates the row with the same values in the key fields, which must match a unique
+// index. If that row is loaded, its object is updated with the fields of the given object and returned, so the
+// wrapper never holds two objects for one row
+func (w *Wrapper[T]) Upsert(val T, keyFields ...string) (T, error) {
+	var obj T
+
+	result, err := w.schema.upsert(w.tx, val, keyFields)
+	if err != nil {
+		return val, err
+	}
+
+	// Cast the object to the generic type and return
+	obj, ok := result.(T)
+	if !ok {
+		return obj, fmt.Errorf("cannot cast object in schema to custom type")
+	}
+
+	return obj, nil
+}
+
+// Update updates an entry, only writing the fields that changed
 func (w *Wrapper[T]) Update(val T) error {
-	return w.schema.update(val)
+	return w.schema.update(w.tx, val)
+}
+
+// UpdateFields updates the given struct fields of an entry, leaving its other fields untouched in the database
+func (w *Wrapper[T]) UpdateFields(val T, fields ...string) error {
+	return w.schema.updateFields(w.tx, val, fields)
+}
+
+// Increment atomically adds to a number field of an entry in SQL and sets the field to the resulting value
+func (w *Wrapper[T]) Increment(val T, field string, delta interface{}) error {
+	return w.schema.increment(w.tx, val, field, delta)
+}
+
+// UpdateExpr atomically sets a field of an entry to the result of an SQL expression and sets the field to the
+// resulting value. The arguments fill the placeholders of the expression
+func (w *Wrapper[T]) UpdateExpr(val T, field string, expr string, args ...interface{}) error {
+	return w.schema.updateExpr(w.tx, val, field, expr, args)
+}
+
+// UpdateReturningOld updates an entry and returns a copy of it as it was last written to or read from the database
+func (w *Wrapper[T]) UpdateReturningOld(val T) (T, error) {
+	var old T
+
+	result, err := w.schema.updateReturningOld(w.tx, val)
+	if err != nil {
+		return old, err
+	}
+
+	// Cast the object to the generic type and return
+	old, ok := result.(T)
+	if !ok {
+		return old, fmt.Errorf("cannot cast object in schema to custom type")
+	}
+
+	return old, nil
+}
+
+// Changes gets the fields of an entry that changed since it was last written to or read from the database. Update
+// and Save only write these fields
+func (w *Wrapper[T]) Changes(val T) ([]FieldChange, error) {
+	return w.schema.changes(val)
 }
 
 // Delete deletes an entry
 func (w *Wrapper[T]) Delete(val T) error {
-	return w.schema.delete(val)
+	return w.schema.delete(w.tx, val)
+}
+
+// DeleteWhere deletes every entry that matches an SQL condition with one statement and returns the number of
+// deleted entries. The arguments fill the placeholders of the condition
+func (w *Wrapper[T]) DeleteWhere(cond string, args ...interface{}) (int, error) {
+	return w.schema.deleteWhere(w.tx, cond, args)
+}
+
+// UpdateWhere sets fields of every entry that matches an SQL condition with one statement and returns the number of
+// updated entries. The changes map struct field names to new values, and the arguments fill the placeholders of the
+// condition
+func (w *Wrapper[T]) UpdateWhere(cond string, changes map[string]interface{}, args ...interface{}) (int, error) {
+	return w.schema.updateWhere(w.tx, cond, changes, args)
+}
+
+// AddRelation appends a value to a slice relation field of an object, only inserting the new link
+func (w *Wrapper[T]) AddRelation(val T, field string, value interface{}) error {
+	return w.schema.addRelation(w.tx, val, field, value)
+}
+
+// RemoveRelation removes a value from a relation field of an object, only deleting its link
+func (w *Wrapper[T]) RemoveRelation(val T, field string, value interface{}) error {
+	return w.schema.removeRelation(w.tx, val, field, value)
+}
+
+// SetRelation replaces the values of a relation field of an object, only changing the links that differ
+func (w *Wrapper[T]) SetRelation(val T, field string, values ...interface{}) error {
+	return w.schema.setRelation(w.tx, val, field, values)
 }
 
 // Read reads an existing SQL table to populate the schema
 func (w *Wrapper[T]) Read() error {
-	return w.schema.read()
+	return w.schema.read(w.tx)
+}
+
+// Reload reads an entry from the database into the object. If its row no longer exists, the object is removed
+func (w *Wrapper[T]) Reload(val T) error {
+	return w.schema.reload(w.tx, val)
+}
+
+// Refresh reads the SQL table again, updating loaded objects in place so references to them stay valid and removing
+// objects whose rows no longer exist
+func (w *Wrapper[T]) Refresh() error {
+	return w.schema.refresh(w.tx)
+}
+
+// LockForUpdate locks an entry with SELECT ... FOR UPDATE in the transaction of the wrapper and reads the locked
+// values into its object. The lock is released when the transaction is committed or rolled back
+func (w *Wrapper[T]) LockForUpdate(id int, option ...LockOption) (T, error) {
+	return w.lock(id, "FOR UPDATE", option)
+}
+
+// LockShared locks an entry with SELECT ... FOR SHARE in the transaction of the wrapper and reads the locked values
+// into its object. The lock is released when the transaction is committed or rolled back
+func (w *Wrapper[T]) LockShared(id int, option ...LockOption) (T, error) {
+	return w.lock(id, "FOR SHARE", option)
+}
+
+// lock is a helper method that locks an entry and casts it to the generic type
+func (w *Wrapper[T]) lock(id int, mode string, options []LockOption) (T, error) {
+	var obj T
+
+	val, err := w.schema.lock(w.tx, id, mode, options)
+	if err != nil {
+		return obj, err
+	}
+
+	// Cast the object to the generic type and return
+	obj, ok := val.(T)
+	if !ok {
+		return obj, fmt.Errorf("cannot cast object with given id to custom type")
+	}
+
+	return obj, nil
 }
 
 // Create a new Schema